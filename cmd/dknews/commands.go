@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deusflow/News/internal/config"
+	"github.com/deusflow/News/internal/media"
+	"github.com/deusflow/News/internal/news"
+	"github.com/deusflow/News/internal/ratelimit"
+	"github.com/deusflow/News/internal/rss"
+	"github.com/deusflow/News/internal/storage"
+)
+
+const usage = `Usage: dknews <command> [flags]
+
+Commands:
+  run               fetch, summarize and send news once (default)
+  daemon            keep running and follow SCHEDULE / QUIET_HOURS
+  dry-run           run the whole pipeline and print exact Telegram payloads instead of sending
+                    (--html preview.html also writes a browser preview)
+  fetch             dump parsed feed items as JSON
+  score             explain category, score, matched keywords and applied rules per feed item
+  cache stats       show sent-news, translation cache and AI usage statistics
+  cache purge       remove expired sent-news records (--all removes everything)
+  cache export      dump sent-news records as JSON
+  feeds validate    check feeds config (--online also downloads every feed)
+  feeds health      show failures, disabled and stale feeds from the stored fetch history
+  image <url>       validate an article image like the pipeline does and print its placeholder hash
+
+Configuration comes from environment variables (see .env.example).
+`
+
+// fetchedItem is the JSON shape printed by "dknews fetch"
+type fetchedItem struct {
+	Source      string     `json:"source"`
+	Lang        string     `json:"lang"`
+	Title       string     `json:"title"`
+	Link        string     `json:"link"`
+	Published   *time.Time `json:"published,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Categories  []string   `json:"categories,omitempty"`
+}
+
+// loadFeedItems loads the feeds config (overridable with --feeds) and fetches all active feeds
+func loadFeedItems(cfg *config.Config, path string) ([]*rss.FeedItem, error) {
+	feeds, err := rss.LoadFeeds(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feeds %s: %v", path, err)
+	}
+	rss.SetRequestTimeout(cfg.RequestTimeout)
+	return rss.FetchAllFeeds(context.Background(), feeds)
+}
+
+func cmdFetch(args []string, cfg *config.Config, out io.Writer) error {
+	fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+	feedsPath := fs.String("feeds", cfg.FeedsConfigPath, "feeds config path")
+	fs.Parse(args)
+
+	items, err := loadFeedItems(cfg, *feedsPath)
+	if err != nil {
+		return err
+	}
+
+	result := make([]fetchedItem, 0, len(items))
+	for _, it := range items {
+		fi := fetchedItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			Published:   it.PublishedParsed,
+			Description: it.Description,
+			Categories:  it.Categories,
+		}
+		if it.Source != nil {
+			fi.Source, fi.Lang = it.Source.Name, it.Source.Lang
+		}
+		result = append(result, fi)
+	}
+	return writeJSON(out, result)
+}
+
+func cmdScore(args []string, cfg *config.Config, out io.Writer) error {
+	fs := flag.NewFlagSet("score", flag.ExitOnError)
+	feedsPath := fs.String("feeds", cfg.FeedsConfigPath, "feeds config path")
+	all := fs.Bool("all", false, "include items rejected by scoring (score 0)")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	rulesPath := fs.String("rules", cfg.ScoringRulesPath, "scoring rules path")
+	fs.Parse(args)
+
+	if err := news.LoadRules(*rulesPath); err != nil {
+		return err
+	}
+	items, err := loadFeedItems(cfg, *feedsPath)
+	if err != nil {
+		return err
+	}
+
+	var results []news.ScoreResult
+	for _, it := range items {
+		r := news.ScoreItem(it)
+		if r.Score == 0 && !*all {
+			continue
+		}
+		results = append(results, r)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+
+	if *asJSON {
+		return writeJSON(out, results)
+	}
+	for _, r := range results {
+		category := r.Category
+		if category == "" {
+			category = "-"
+		}
+		fmt.Fprintf(out, "%4d  %-12s %s\n", r.Score, category, r.Title)
+		if r.Source != "" {
+			fmt.Fprintf(out, "      source: %s\n", r.Source)
+		}
+		if r.Rejected != "" {
+			fmt.Fprintf(out, "      rejected: %s\n", r.Rejected)
+		}
+		for _, st := range r.Steps {
+			fmt.Fprintf(out, "      %+5d  %s\n", st.Delta, st.Rule)
+		}
+		groups := make([]string, 0, len(r.Matched))
+		for g := range r.Matched {
+			groups = append(groups, g)
+		}
+		sort.Strings(groups)
+		for _, g := range groups {
+			fmt.Fprintf(out, "      %s: %s\n", g, strings.Join(r.Matched[g], ", "))
+		}
+	}
+	fmt.Fprintf(out, "\n%d of %d items shown\n", len(results), len(items))
+	return nil
+}
+
+func cmdCache(args []string, cfg *config.Config, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("cache: expected stats, purge or export")
+	}
+	sub, args := args[0], args[1:]
+
+	var fileCache *storage.FileCache
+	var pgCache *storage.PostgresCache
+	if cfg.UsePostgres && cfg.DatabaseURL != "" {
+		pg, err := storage.NewPostgresCache(cfg.DatabaseURL, cfg.DatabaseTTL)
+		if err != nil {
+			return err
+		}
+		defer pg.Close()
+		pgCache = pg
+	} else {
+		fileCache = storage.NewFileCache(cfg.CacheFilePath, cfg.CacheTTLHours)
+		if err := fileCache.Load(); err != nil {
+			return err
+		}
+	}
+
+	switch sub {
+	case "stats":
+		return cacheStats(cfg, fileCache, pgCache, out)
+	case "purge":
+		fs := flag.NewFlagSet("cache purge", flag.ExitOnError)
+		all := fs.Bool("all", false, "remove every sent-news record, not only expired ones (already sent news may be posted again)")
+		fs.Parse(args)
+		if pgCache != nil {
+			n, err := pgCache.Purge(*all)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Removed %d sent-news records\n", n)
+			return nil
+		}
+		n := fileCache.Purge(*all)
+		if err := fileCache.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Removed %d sent-news records\n", n)
+		return nil
+	case "export":
+		if pgCache != nil {
+			items, err := pgCache.ListSentNews()
+			if err != nil {
+				return err
+			}
+			return writeJSON(out, items)
+		}
+		return writeJSON(out, fileCache.Items())
+	default:
+		return fmt.Errorf("cache: unknown subcommand %q (expected stats, purge or export)", sub)
+	}
+}
+
+func cacheStats(cfg *config.Config, fileCache *storage.FileCache, pgCache *storage.PostgresCache, out io.Writer) error {
+	stats := map[string]interface{}{}
+	limiterStore := ratelimit.Store(ratelimit.NewFileStore(cfg.RateLimitStatePath))
+
+	if pgCache != nil {
+		sent, err := pgCache.GetStats()
+		if err != nil {
+			return err
+		}
+		stats["backend"] = "postgres"
+		stats["sent_news"] = sent
+		limiterStore = pgCache
+	} else {
+		stats["backend"] = "file"
+		stats["sent_news"] = fileCache.GetStats()
+
+		translations := storage.NewFileTranslationCache(cfg.TranslationCachePath)
+		if err := translations.Load(); err != nil {
+			return err
+		}
+		stats["translation_cache"] = translations.GetStats()
+	}
+
+	state, err := limiterStore.LoadRateLimitState()
+	if err != nil {
+		return err
+	}
+	stats["ai_usage"] = state
+	return writeJSON(out, stats)
+}
+
+func cmdFeeds(args []string, cfg *config.Config, out io.Writer) error {
+	if len(args) > 0 && args[0] == "health" {
+		return feedsHealth(args[1:], cfg, out)
+	}
+	if len(args) == 0 || args[0] != "validate" {
+		return fmt.Errorf("feeds: expected validate or health")
+	}
+	fs := flag.NewFlagSet("feeds validate", flag.ExitOnError)
+	feedsPath := fs.String("feeds", cfg.FeedsConfigPath, "feeds config path")
+	online := fs.Bool("online", false, "download and parse every active feed")
+	timeout := fs.Duration("timeout", 15*time.Second, "per-feed timeout for --online")
+	fs.Parse(args[1:])
+
+	feeds, err := rss.LoadFeeds(*feedsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load feeds %s: %v", *feedsPath, err)
+	}
+
+	problems := rss.ValidateFeeds(feeds)
+	for _, p := range problems {
+		fmt.Fprintf(out, "❌ %v\n", p)
+	}
+
+	failed := 0
+	if *online {
+		for _, f := range feeds {
+			if !f.Active {
+				fmt.Fprintf(out, "⏸️  %s: inactive, skipped\n", f.Name)
+				continue
+			}
+			n, err := rss.CheckFeed(f, *timeout)
+			switch {
+			case err != nil:
+				failed++
+				fmt.Fprintf(out, "❌ %s: %v\n", f.Name, err)
+			case n == 0:
+				failed++
+				fmt.Fprintf(out, "⚠️  %s: feed has no items\n", f.Name)
+			default:
+				fmt.Fprintf(out, "✅ %s: %d items\n", f.Name, n)
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "%d feeds, %d config problems, %d unreachable\n", len(feeds), len(problems), failed)
+	if len(problems) > 0 || failed > 0 {
+		return fmt.Errorf("feeds validation failed")
+	}
+	return nil
+}
+
+func feedsHealth(args []string, cfg *config.Config, out io.Writer) error {
+	fs := flag.NewFlagSet("feeds health", flag.ExitOnError)
+	feedsPath := fs.String("feeds", cfg.FeedsConfigPath, "feeds config path")
+	staleDays := fs.Int("stale-days", cfg.FeedStaleDays, "report feeds without new items for this many days")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	fs.Parse(args)
+
+	feeds, err := rss.LoadFeeds(*feedsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load feeds %s: %v", *feedsPath, err)
+	}
+
+	var store rss.StateStore = rss.NewFileStateStore(cfg.FeedStatePath)
+	if cfg.UsePostgres && cfg.DatabaseURL != "" {
+		pg, err := storage.NewPostgresCache(cfg.DatabaseURL, cfg.DatabaseTTL)
+		if err != nil {
+			return err
+		}
+		defer pg.Close()
+		store = pg
+	}
+	states, err := store.LoadFeedStates()
+	if err != nil {
+		return err
+	}
+
+	report := rss.BuildHealthReport(feeds, states, time.Duration(*staleDays)*24*time.Hour, time.Now())
+	if *asJSON {
+		return writeJSON(out, report)
+	}
+	for _, r := range report {
+		h := r.Health
+		name := r.Name
+		if !r.Active {
+			name += " (inactive)"
+		}
+		fmt.Fprintf(out, "%-9s %s\n          %s\n", r.Status, name, r.URL)
+		if r.Status == "unknown" {
+			continue
+		}
+		fmt.Fprintf(out, "          failures in a row: %d, parse errors: %d, avg items: %.1f\n", h.ConsecutiveFailures, h.ParseErrors, h.AvgItems)
+		fmt.Fprintf(out, "          last success: %s, newest item: %s\n", formatTime(h.LastSuccess), formatTime(h.NewestItem))
+		if h.LastError != "" {
+			fmt.Fprintf(out, "          last error: %s\n", h.LastError)
+		}
+		if r.Status == "disabled" {
+			fmt.Fprintf(out, "          next probe: %s\n", formatTime(h.DisabledUntil))
+		}
+	}
+	return nil
+}
+
+func cmdImage(args []string, cfg *config.Config, out io.Writer) error {
+	fs := flag.NewFlagSet("image", flag.ExitOnError)
+	placeholdersPath := fs.String("placeholders", cfg.ImagePlaceholdersPath, "placeholder hashes file")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("image: expected one image URL")
+	}
+
+	placeholders, err := media.LoadPlaceholders(*placeholdersPath)
+	if err != nil {
+		return err
+	}
+	media.SetRequestTimeout(cfg.RequestTimeout)
+	check := media.Validate(context.Background(), fs.Arg(0), media.Rules{
+		MinWidth:     cfg.ImageMinWidth,
+		MinHeight:    cfg.ImageMinHeight,
+		Placeholders: placeholders,
+	})
+
+	verdict := "✅ photo"
+	if !check.OK {
+		verdict = "❌ text"
+	}
+	fmt.Fprintf(out, "%s: %s\n", verdict, check.Reason)
+	if check.Hash != 0 {
+		fmt.Fprintf(out, "hash: %s\n", media.FormatHash(check.Hash))
+	}
+	if check.Image != nil && check.Image.Transcoded {
+		fmt.Fprintf(out, "upload: %dx%d jpeg, %d bytes (converted from %s)\n", check.Image.Width, check.Image.Height, len(check.Image.Data), check.Image.SourceFormat)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func writeJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// exitOnError prints err and exits with status 1
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
@@ -2,21 +2,56 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 
 	"github.com/deusflow/News/internal/app"
+	"github.com/deusflow/News/internal/config"
 	"github.com/deusflow/News/internal/metrics"
 )
 
 func main() {
-	// Check if we should start HTTP server for monitoring
-	if os.Getenv("ENABLE_HTTP_MONITORING") == "true" {
-		go startMonitoringServer()
+	cmd, args := "run", os.Args[1:]
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
 	}
 
-	app.Run()
+	switch cmd {
+	case "run", "daemon", "dry-run":
+		// Check if we should start HTTP server for monitoring
+		if os.Getenv("ENABLE_HTTP_MONITORING") == "true" {
+			go startMonitoringServer()
+		}
+		switch cmd {
+		case "daemon":
+			app.RunDaemon()
+		case "dry-run":
+			fs := flag.NewFlagSet("dry-run", flag.ExitOnError)
+			htmlPath := fs.String("html", "", "also write an HTML preview to this file")
+			fs.Parse(args)
+			app.RunDryRun(*htmlPath)
+		default:
+			app.Run()
+		}
+	case "fetch":
+		exitOnError(cmdFetch(args, config.LoadEnv(), os.Stdout))
+	case "score":
+		exitOnError(cmdScore(args, config.LoadEnv(), os.Stdout))
+	case "cache":
+		exitOnError(cmdCache(args, config.LoadEnv(), os.Stdout))
+	case "feeds":
+		exitOnError(cmdFeeds(args, config.LoadEnv(), os.Stdout))
+	case "image":
+		exitOnError(cmdImage(args, config.LoadEnv(), os.Stdout))
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
 }
 
 func startMonitoringServer() {
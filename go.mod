@@ -7,6 +7,7 @@ require (
 	github.com/google/generative-ai-go v0.20.1
 	github.com/lib/pq v1.10.9
 	github.com/mmcdole/gofeed v1.3.0
+	golang.org/x/image v0.25.0
 	google.golang.org/api v0.186.0
 	gopkg.in/yaml.v3 v3.0.1
 )
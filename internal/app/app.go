@@ -1,78 +1,83 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"html"
 	"log"
+	"os"
+	"os/signal"
 	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/deusflow/News/internal/config"
 	"github.com/deusflow/News/internal/gemini"
 	"github.com/deusflow/News/internal/logger"
+	"github.com/deusflow/News/internal/media"
 	"github.com/deusflow/News/internal/metrics"
 	"github.com/deusflow/News/internal/news"
+	"github.com/deusflow/News/internal/ratelimit"
 	"github.com/deusflow/News/internal/rss"
+	"github.com/deusflow/News/internal/scraper"
 	"github.com/deusflow/News/internal/storage"
 	"github.com/deusflow/News/internal/telegram"
+	"github.com/deusflow/News/internal/translate"
 )
 
-// formatNewsMessage builds grouped message using AI summaries (Ukrainian priority, then Danish, then others)
-func formatNewsMessage(newsList []news.News, max int) string {
+// digestSections are the groups of the digest, in posting order; bilingual digests use the Ukrainian titles
+var digestSections = []struct {
+	uk, da string
+	match  func(n news.News) bool
+}{
+	// Priority: Ukraine in Denmark
+	{"🇺🇦 <b>УКРАЇНА В ДАНІЇ</b>", "🇺🇦 <b>UKRAINE I DANMARK</b>", func(n news.News) bool { return n.Category == "ukraine" }},
+	// Then important Denmark
+	{"🇩🇰 <b>ВАЖЛИВІ НОВИНИ ДАНІЇ</b>", "🇩🇰 <b>VIGTIGE NYHEDER FRA DANMARK</b>", func(n news.News) bool { return n.Category == "denmark" }},
+	// Then everything else to increase diversity
+	{"🌍 <b>ІНШІ ВАЖЛИВІ НОВИНИ</b>", "🌍 <b>ANDRE VIGTIGE NYHEDER</b>", func(n news.News) bool { return n.Category != "ukraine" && n.Category != "denmark" }},
+}
+
+// formatNewsMessage builds grouped message using AI summaries (Ukrainian priority, then Danish, then others).
+// Headers follow layout; empty sections are left out; the result may exceed the Telegram limit (see telegram.SplitHTML).
+func formatNewsMessage(newsList []news.News, max int, layout news.Layout) string {
 	var b strings.Builder
 
-	b.WriteString("🇩🇰 <b>Новини Данії</b> 🇺🇦\n")
+	b.WriteString(layout.Pick("🇩🇰 <b>Новини Данії</b> 🇺🇦", "🇩🇰 <b>Danske nyheder</b>", "🇺🇦 <b>Новини Данії</b>") + "\n")
 	b.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 
 	count := 1
-
-	// Priority: Ukraine in Denmark
-	b.WriteString("🇺🇦 <b>УКРАЇНА В ДАНІЇ</b>\n\n")
-	for _, n := range newsList {
-		if count > max {
-			break
-		}
-		if n.Category == "ukraine" {
-			b.WriteString(formatSingleNews(n, count))
-			count++
-		}
-	}
-
-	// Then important Denmark
-	if count <= max {
-		b.WriteString("\n🇩🇰 <b>ВАЖЛИВІ НОВИНИ ДАНІЇ</b>\n\n")
+	for _, section := range digestSections {
+		started := false
 		for _, n := range newsList {
 			if count > max {
 				break
 			}
-			if n.Category == "denmark" {
-				b.WriteString(formatSingleNews(n, count))
-				count++
+			if !section.match(n) {
+				continue
 			}
-		}
-	}
-
-	// Then everything else to increase diversity
-	if count <= max {
-		b.WriteString("\n🌍 <b>ІНШІ ВАЖЛИВІ НОВИНИ</b>\n\n")
-		for _, n := range newsList {
-			if count > max {
-				break
-			}
-			if n.Category != "ukraine" && n.Category != "denmark" {
-				b.WriteString(formatSingleNews(n, count))
-				count++
+			if !started {
+				if count > 1 {
+					b.WriteString("\n")
+				}
+				b.WriteString(layout.Pick(section.uk, section.da, section.uk) + "\n\n")
+				started = true
 			}
+			b.WriteString(formatSingleNews(n, count))
+			count++
 		}
 	}
 
 	b.WriteString("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	b.WriteString("📱 Danish News Bot | Щодня о 8:00 UTC")
+	b.WriteString(layout.Pick("📱 Danish News Bot | Щодня о 8:00 UTC", "📱 Danish News Bot | Hver dag kl. 8:00 UTC", "📱 Danish News Bot | Щодня о 8:00 UTC"))
 
 	return b.String()
 }
 
-// formatSingleNews now uses AI summaries instead of full translations
+// formatSingleNews now uses AI summaries instead of full translations (languages per n.Layout)
 func formatSingleNews(n news.News, number int) string {
 	var b strings.Builder
 
@@ -83,16 +88,16 @@ func formatSingleNews(n news.News, number int) string {
 	}
 
 	// Title with link
-	b.WriteString(fmt.Sprintf("%s <b>%d.</b> <a href=\"%s\">%s</a>\n", emoji, number, n.Link, n.Title))
+	b.WriteString(fmt.Sprintf("%s <b>%d.</b> <a href=\"%s\">%s</a>\n", emoji, number, html.EscapeString(n.Link), html.EscapeString(n.Title)))
 
 	// Ukrainian summary (primary)
-	if n.SummaryUkrainian != "" {
-		b.WriteString(fmt.Sprintf("🇺🇦 <i>%s</i>\n", limitText(n.SummaryUkrainian, 1500)))
+	if n.SummaryUkrainian != "" && n.Layout != news.LayoutDanish {
+		b.WriteString(fmt.Sprintf("🇺🇦 <i>%s</i>\n", html.EscapeString(limitText(n.SummaryUkrainian, 1500))))
 	}
 
 	// Danish summary (secondary)
-	if n.SummaryDanish != "" {
-		b.WriteString(fmt.Sprintf("🇩🇰 %s\n", limitText(n.SummaryDanish, 1500)))
+	if n.SummaryDanish != "" && n.Layout != news.LayoutUkrainian {
+		b.WriteString(fmt.Sprintf("🇩🇰 %s\n", html.EscapeString(limitText(n.SummaryDanish, 1500))))
 	}
 
 	b.WriteString("➖➖➖➖➖➖➖➖➖➖\n\n")
@@ -100,116 +105,388 @@ func formatSingleNews(n news.News, number int) string {
 	return b.String()
 }
 
+// limitText cuts s to about max runes at a word boundary
 func limitText(s string, max int) string {
-	if len(s) <= max {
+	r := []rune(s)
+	if len(r) <= max {
 		return s
 	}
-	cut := s[:max]
+	cut := string(r[:max])
 	if i := strings.LastIndex(cut, " "); i > 400 {
 		cut = cut[:i]
 	}
 	return strings.TrimSpace(cut) + "..."
 }
 
-// Run запускает основной процесс приложения с инициализацией Gemini
-func Run() {
+// App holds clients and caches that are reused between pipeline runs (one run for Run, many for RunDaemon)
+type App struct {
+	cfg          *config.Config
+	cacheAdapter CacheAdapter
+	pgCache      *storage.PostgresCache
+	limiter      *ratelimit.AIRateLimiter
+	gmClient     *gemini.Client
+	sender       telegram.Sender
+	photos       storage.PhotoStore // file_ids of uploaded images
+	imageRules   *media.Rules       // nil when IMAGE_VALIDATION=false
+	feedStates   rss.StateStore     // ETag/Last-Modified between runs
+	savers       []func()           // persist file-based caches
+	closers      []func()
+	preview      *preview     // dry run: collect messages instead of sending them
+	report       *news.Report // scoring decisions of the current run (nil unless SCORE_REPORT_PATH is set)
+}
+
+// loadConfig initializes logging and loads configuration, exiting on invalid config.
+// Telegram credentials are not required for dry runs.
+func loadConfig(dryRun bool) *config.Config {
 	// Initialize structured logging
 	logger.Init()
 	logger.Info("Starting Danish News Bot")
 
 	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
+	cfg := config.LoadEnv()
+	validate := cfg.Validate
+	if dryRun {
+		validate = cfg.ValidatePipeline
+	}
+	if err := validate(); err != nil {
 		logger.Error("Failed to load configuration", "error", err)
 		log.Fatalf("Ошибка конфигурации: %v", err)
 	}
 	logger.Info("Configuration loaded successfully", "mode", cfg.BotMode, "max_news", cfg.MaxNewsLimit, "use_postgres", cfg.UsePostgres)
+	return cfg
+}
 
-	// Initialize cache system (PostgreSQL or File-based)
-	var cacheAdapter CacheAdapter
+// Run запускает основной процесс приложения с инициализацией Gemini
+func Run() {
+	cfg := loadConfig(false)
 
+	a, err := New(cfg)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации: %v", err)
+	}
+	defer a.Close()
+
+	// SIGINT/SIGTERM cancel in-flight fetch, scrape and AI requests
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := a.RunOnce(ctx); err != nil {
+		log.Fatalf("Ошибка выполнения: %v", err)
+	}
+}
+
+// RunDryRun runs the whole pipeline, including duplicate checks and posting policy, but never calls
+// Telegram, marks anything as sent or saves caches, feed state and AI usage. Exact payloads go to stdout and, if htmlPath is set, to an HTML preview.
+func RunDryRun(htmlPath string) {
+	cfg := loadConfig(true)
+
+	a, err := newApp(cfg, true)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации: %v", err)
+	}
+	defer a.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := a.RunOnce(ctx); err != nil {
+		log.Fatalf("Ошибка выполнения: %v", err)
+	}
+
+	a.preview.writeText(os.Stdout)
+	if htmlPath != "" {
+		if err := a.preview.writeHTML(htmlPath); err != nil {
+			log.Fatalf("Ошибка превью: %v", err)
+		}
+		logger.Info("HTML preview written", "path", htmlPath)
+	}
+}
+
+// New initializes caches, AI rate limiter, Gemini client and the AI provider chain
+func New(cfg *config.Config) (*App, error) {
+	return newApp(cfg, false)
+}
+
+// newApp builds the App; a dry run reads caches, feed state and quotas but never writes them back
+func newApp(cfg *config.Config, dryRun bool) (*App, error) {
+	a := &App{cfg: cfg}
+	if dryRun {
+		a.preview = &preview{}
+	}
+
+	// Per-request timeouts for every outgoing HTTP call
+	rss.SetRequestTimeout(cfg.RequestTimeout)
+	scraper.SetRequestTimeout(cfg.RequestTimeout)
+	media.SetRequestTimeout(cfg.RequestTimeout)
+
+	tgClient := telegram.NewClient(cfg.TelegramToken)
+	tgClient.SetBaseURL(cfg.TelegramAPIURL)
+	tgClient.SetRequestTimeout(cfg.RequestTimeout)
+	tgClient.SetLimits(telegram.Limits{GlobalPerSecond: cfg.TelegramGlobalPerSecond, GroupPerMinute: cfg.TelegramChatPerMinute})
+	a.sender = tgClient
+
+	if err := news.LoadRules(cfg.ScoringRulesPath); err != nil {
+		return nil, err
+	}
+	if cfg.ValidateImages {
+		placeholders, err := media.LoadPlaceholders(cfg.ImagePlaceholdersPath)
+		if err != nil {
+			return nil, err
+		}
+		a.imageRules = &media.Rules{MinWidth: cfg.ImageMinWidth, MinHeight: cfg.ImageMinHeight, Placeholders: placeholders}
+	}
+
+	// AI rate limiter state and translation cache go next to the sent-news cache
+	var limiterStore ratelimit.Store = ratelimit.NewFileStore(cfg.RateLimitStatePath)
+	var translationStore storage.TranslationStore
+
+	// Initialize cache system (PostgreSQL or File-based)
 	if cfg.UsePostgres && cfg.DatabaseURL != "" {
 		// Use PostgreSQL for production-grade duplicate prevention
 		pgCache, err := storage.NewPostgresCache(cfg.DatabaseURL, cfg.DatabaseTTL)
 		if err != nil {
 			logger.Error("Failed to connect to PostgreSQL, falling back to file cache", "error", err)
 			// Fallback to file cache
-			fileCache := storage.NewFileCache(cfg.CacheFilePath, cfg.CacheTTLHours)
-			if err := fileCache.Load(); err != nil {
-				logger.Error("Failed to load file cache", "error", err)
-			}
-			cacheAdapter = &FileCacheAdapter{cache: fileCache}
+			a.useFileCache()
 		} else {
 			logger.Info("PostgreSQL cache initialized successfully")
-			// Cleanup old records
-			if err := pgCache.Cleanup(); err != nil {
-				logger.Warn("Failed to cleanup old records", "error", err)
-			}
-			cacheAdapter = &PostgresCacheAdapter{cache: pgCache}
-			defer pgCache.Close()
+			a.cacheAdapter = &PostgresCacheAdapter{cache: pgCache}
+			a.pgCache = pgCache
+			limiterStore = pgCache
+			translationStore = pgCache
+			a.photos = pgCache
+			a.feedStates = pgCache
+			a.closers = append(a.closers, func() { pgCache.Close() })
 		}
 	} else {
 		// Use file-based cache
 		logger.Info("Using file-based cache")
-		newsCache := storage.NewFileCache(cfg.CacheFilePath, cfg.CacheTTLHours)
-		if err := newsCache.Load(); err != nil {
-			logger.Error("Failed to load news cache", "error", err)
+		a.useFileCache()
+	}
+
+	if a.feedStates == nil {
+		a.feedStates = rss.NewFileStateStore(cfg.FeedStatePath)
+	}
+	if dryRun {
+		a.feedStates = readOnlyFeedStates{a.feedStates}
+		limiterStore = readOnlyLimiterStore{limiterStore}
+	}
+
+	if translationStore == nil {
+		fileTranslations := storage.NewFileTranslationCache(cfg.TranslationCachePath)
+		if err := fileTranslations.Load(); err != nil {
+			logger.Error("Failed to load translation cache", "error", err)
 		} else {
-			logger.Info("News cache loaded successfully", "items", newsCache.GetStats()["total_items"])
+			logger.Info("Translation cache loaded", "items", fileTranslations.GetStats()["total_items"])
 		}
-		cacheAdapter = &FileCacheAdapter{cache: newsCache}
-		defer func() {
-			if fc, ok := cacheAdapter.(*FileCacheAdapter); ok {
-				if err := fc.cache.Save(); err != nil {
-					logger.Error("Failed to save news cache", "error", err)
-				}
+		translationStore = fileTranslations
+		a.savers = append(a.savers, func() {
+			if err := fileTranslations.Save(); err != nil {
+				logger.Error("Failed to save translation cache", "error", err)
 			}
-		}()
+		})
+	}
+
+	if a.photos == nil {
+		filePhotos := storage.NewFilePhotoCache(cfg.PhotoCachePath)
+		if err := filePhotos.Load(); err != nil {
+			logger.Error("Failed to load photo cache", "error", err)
+		}
+		a.photos = filePhotos
+		a.savers = append(a.savers, func() {
+			if err := filePhotos.Save(); err != nil {
+				logger.Error("Failed to save photo cache", "error", err)
+			}
+		})
+	}
+
+	// Initialize AI rate limiter (per-run limits + persisted daily quotas)
+	a.limiter = ratelimit.NewAIRateLimiter(cfg.MaxGeminiRequests, cfg.MaxGroqRequests, cfg.MaxCohereRequests, cfg.MaxMistralRequests, cfg.MaxTotalAIRequests)
+	a.limiter.SetDailyLimits(ratelimit.Limits{
+		Gemini:  cfg.DailyGeminiRequests,
+		Groq:    cfg.DailyGroqRequests,
+		Cohere:  cfg.DailyCohereRequests,
+		Mistral: cfg.DailyMistralRequests,
+		Total:   cfg.DailyTotalAIRequests,
+	})
+	if err := a.limiter.Attach(limiterStore); err != nil {
+		logger.Warn("Failed to restore AI rate limiter state, starting from zero", "error", err)
 	}
 
 	// Initialize Gemini client
 	gmClient, err := gemini.NewClient(cfg.GeminiAPIKey)
 	if err != nil {
 		logger.Error("Failed to initialize Gemini client", "error", err)
-		log.Fatalf("Ошибка инициализации Gemini: %v", err)
+		a.Close()
+		return nil, fmt.Errorf("failed to initialize Gemini: %v", err)
 	}
-	defer gmClient.Close()
-	news.SetGeminiClient(gmClient)
+	a.gmClient = gmClient
+	a.closers = append(a.closers, gmClient.Close)
+	gmClient.SetRateLimiter(a.limiter)
+	gmClient.SetTranslationStore(translationStore)
+	gmClient.SetRequestTimeout(cfg.RequestTimeout)
 	logger.Info("Gemini client initialized successfully")
 
+	// Build AI provider chain; Gemini SDK client replaces the built-in REST "gemini" provider
+	aiRegistry, err := translate.BuildRegistry(cfg.AIProviders, gmClient)
+	if err != nil {
+		logger.Error("Failed to build AI provider chain", "error", err)
+		a.Close()
+		return nil, fmt.Errorf("invalid AI_PROVIDERS: %v", err)
+	}
+	aiRegistry.SetLimiter(a.limiter)
+	aiRegistry.SetStore(translationStore)
+	aiRegistry.SetRequestTimeout(cfg.RequestTimeout)
+	translate.SetDefault(aiRegistry)
+	news.SetAIRegistry(aiRegistry)
+	logger.Info("AI providers configured", "order", aiRegistry.Names())
+
+	return a, nil
+}
+
+// useFileCache loads the JSON sent-news cache and registers it for saving
+func (a *App) useFileCache() {
+	newsCache := storage.NewFileCache(a.cfg.CacheFilePath, a.cfg.CacheTTLHours)
+	if err := newsCache.Load(); err != nil {
+		logger.Error("Failed to load news cache", "error", err)
+	} else {
+		logger.Info("News cache loaded successfully", "items", newsCache.GetStats()["total_items"])
+	}
+	a.cacheAdapter = &FileCacheAdapter{cache: newsCache}
+	a.savers = append(a.savers, func() {
+		if err := newsCache.Save(); err != nil {
+			logger.Error("Failed to save news cache", "error", err)
+		}
+	})
+}
+
+// persist saves file-based caches; called after every run and on Close (never in a dry run)
+func (a *App) persist() {
+	if a.preview != nil {
+		return
+	}
+	for _, save := range a.savers {
+		save()
+	}
+}
+
+// Close persists caches and releases clients and database connections
+func (a *App) Close() {
+	a.persist()
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+	a.closers = nil
+}
+
+// RunOnce executes one fetch→filter→send pipeline, bounded by RUN_TIMEOUT if set.
+// Cancelling ctx aborts fetching, scraping and AI requests; sending stops after the message in flight.
+func (a *App) RunOnce(ctx context.Context) error {
+	cfg := a.cfg
+	if cfg.RunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.RunTimeout)
+		defer cancel()
+	}
+	a.limiter.ResetRun()
+	defer a.persist()
+	defer a.limiter.PrintStats()
+
+	if a.pgCache != nil && a.preview == nil {
+		// Cleanup old records
+		if err := a.pgCache.Cleanup(); err != nil {
+			logger.Warn("Failed to cleanup old records", "error", err)
+		}
+	}
+
 	// Load RSS feeds
 	feeds, err := rss.LoadFeeds(cfg.FeedsConfigPath)
 	if err != nil {
 		logger.Error("Failed to load RSS feeds", "error", err)
-		log.Fatalf("Ошибка загрузки списка RSS: %v", err)
+		return fmt.Errorf("failed to load RSS feeds: %v", err)
 	}
 	logger.Info("RSS feeds loaded", "count", len(feeds))
 
+	// Pick up edits to the scoring rules without restarting the daemon
+	if reloaded, err := news.ReloadRulesIfChanged(); err != nil {
+		logger.Warn("Failed to reload scoring rules, keeping previous rules", "error", err)
+	} else if reloaded {
+		logger.Info("Scoring rules reloaded", "path", cfg.ScoringRulesPath)
+	}
+
 	// Fetch news items
-	items, err := rss.FetchAllFeeds(feeds)
+	items, results, err := rss.FetchFeeds(ctx, feeds, rss.FetchOptions{
+		Concurrency: cfg.FeedConcurrency,
+		Timeout:     cfg.RequestTimeout,
+		States:      a.feedStates,
+
+		DisableAfter:    cfg.FeedDisableAfter,
+		ReprobeInterval: cfg.FeedReprobeInterval,
+		StaleAfter:      time.Duration(cfg.FeedStaleDays) * 24 * time.Hour,
+	})
 	if err != nil {
 		logger.Error("Failed to fetch RSS feeds", "error", err)
-		log.Fatalf("Ошибка парсинга RSS: %v", err)
+		return fmt.Errorf("failed to fetch RSS feeds: %v", err)
+	}
+	logger.Info("News items fetched", "total", len(items), "feeds", len(results))
+
+	batchSize := 0
+	if cfg.EnableBatching {
+		batchSize = cfg.BatchSize
+	}
+
+	if cfg.ScoreReportPath != "" {
+		a.report = news.NewReport()
+		defer func() {
+			if err := a.report.WriteJSON(cfg.ScoreReportPath); err != nil {
+				logger.Error("Failed to write score report", "error", err)
+			} else {
+				logger.Info("Score report written", "path", cfg.ScoreReportPath, "items", len(a.report.Items))
+			}
+			a.report = nil
+		}()
+	}
+
+	// Posting channels with the stories each got within DUPLICATE_WINDOW_HOURS (near-duplicate detection across runs)
+	channels, err := loadChannels(cfg, a.cacheAdapter, time.Now().Add(-time.Duration(cfg.DuplicateWindow)*time.Hour))
+	if err != nil {
+		logger.Error("Failed to load channels", "error", err)
+		return fmt.Errorf("failed to load channels: %v", err)
 	}
-	logger.Info("News items fetched", "total", len(items))
 
 	// Filter and translate news with options from config
-	filtered, err := news.FilterAndTranslateWithOptions(items, news.Options{
-		Limit:             cfg.MaxNewsLimit,
+	filtered, err := news.FilterAndTranslateWithOptions(ctx, items, news.Options{
+		Targets:           targets(channels),
 		MaxAge:            cfg.NewsMaxAge,
 		PerSource:         2,
 		MaxGeminiRequests: cfg.MaxGeminiRequests,
 		ScrapeMaxArticles: cfg.ScrapeMaxArticles,
 		ScrapeConcurrency: cfg.ScrapeConcurrency,
+		BatchSize:         batchSize,
+		ImageRules:        a.imageRules,
+		KeepImages:        cfg.UploadPhotos && a.preview == nil,
+		Report:            a.report,
+
+		Sent:                   sentEverywhere(channels),
+		MaxFingerprintDistance: cfg.MaxFingerprintDistance,
 	})
 	if err != nil {
 		logger.Error("Failed to filter and translate news", "error", err)
-		log.Fatalf("Ошибка фильтрации/обработки: %v", err)
+		return fmt.Errorf("failed to filter and translate news: %v", err)
 	}
 	logger.Info("News filtered and translated", "relevant", len(filtered))
 
-	// Show preview in console
+	if cfg.FollowUpUpdates {
+		for _, ch := range channels {
+			a.sendUpdates(ctx, ch, items)
+		}
+	}
+
+	// Show preview in console (dry run prints the real payloads instead)
 	for i, n := range filtered {
+		if a.preview != nil {
+			break
+		}
 		if i >= 2 {
 			break
 		}
@@ -219,14 +496,36 @@ func Run() {
 
 	if len(filtered) == 0 {
 		logger.Warn("No relevant news found, skipping Telegram send")
-		return
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		logger.Warn("Run cancelled, skipping Telegram send", "reason", err)
+		return nil
 	}
 
-	// Send to Telegram based on mode
-	if cfg.BotMode == "single" {
-		sendSingleNews(filtered, cfg, cacheAdapter)
-	} else {
-		sendMultipleNews(filtered, cfg, cacheAdapter, cfg.MaxNewsLimit)
+	// Send to every channel based on mode; a failing channel does not stop the others
+	var sendErr error
+	for _, ch := range channels {
+		if ctx.Err() != nil {
+			break
+		}
+		list := ch.selectFor(filtered)
+		logger.Info("Posting to channel", "channel", ch.Name, "candidates", len(list), "max", ch.MaxItems)
+		switch cfg.BotMode {
+		case "single":
+			if err := a.sendSingleNews(ctx, ch, list); err != nil && sendErr == nil {
+				sendErr = err
+			}
+		case "digest":
+			if err := a.sendDigest(ctx, ch, list); err != nil && sendErr == nil {
+				sendErr = err
+			}
+		default:
+			a.sendMultipleNews(ctx, ch, list, ch.MaxItems)
+		}
+	}
+	if sendErr != nil {
+		return sendErr
 	}
 
 	// Log final metrics
@@ -237,90 +536,83 @@ func Run() {
 		"duplicates_filtered", stats["duplicates_filtered"],
 		"processing_time_ms", stats["last_processing_time_ms"],
 	)
+	return nil
 }
 
-// sendSingleNews отправляет одну новость
-func sendSingleNews(newsList []news.News, cfg *config.Config, cacheAdapter CacheAdapter) {
+// sendSingleNews отправляет одну новость в канал
+func (a *App) sendSingleNews(ctx context.Context, ch *postingChannel, newsList []news.News) error {
+	cfg, cacheAdapter := a.cfg, a.cacheAdapter
 	if len(newsList) == 0 {
-		logger.Warn("No news to send")
-		return
+		logger.Warn("No news to send", "channel", ch.Name)
+		return nil
 	}
 
-	// Find first non-duplicate news (double check: hash and link)
+	// Find first news the channel did not get yet (hash, link and near-duplicate check)
 	var selectedNews *news.News
 	for i := range newsList {
-		hash := cacheAdapter.GenerateNewsHash(newsList[i].Title, newsList[i].Link)
-
-		// Double check: both hash and direct link
-		if !cacheAdapter.IsAlreadySent(hash) && !cacheAdapter.IsLinkAlreadySent(newsList[i].Link) {
+		if !ch.alreadySent(cacheAdapter, newsList[i], cfg.MaxFingerprintDistance) {
 			selectedNews = &newsList[i]
 			break
 		}
-		logger.Info("Skipping duplicate news", "title", newsList[i].Title, "hash", hash)
+		hash := ch.hash(cacheAdapter, newsList[i])
+		logger.Info("Skipping duplicate news", "title", newsList[i].Title, "hash", hash, "channel", ch.Name)
+		a.report.Reject(newsList[i].Link, "already sent to "+ch.Name)
+		if a.preview != nil {
+			a.preview.addSkipped(newsList[i], "already sent to "+ch.Name+" (hash "+hash+")")
+		}
 	}
 
 	if selectedNews == nil {
-		logger.Warn("All news items are duplicates, nothing to send")
-		return
+		logger.Warn("All news items are duplicates, nothing to send", "channel", ch.Name)
+		return nil
 	}
 
 	// Build caption/message according to policy
-	var outText string
-	usePhoto := false
-	policy := strings.ToLower(strings.TrimSpace(cfg.PostingPolicy))
-	if policy == "" {
-		policy = "hybrid"
-	}
-	canPhoto := strings.TrimSpace(selectedNews.ImageURL) != "" && news.ShouldUsePhoto(*selectedNews, cfg.PhotoCaptionMaxRunes, cfg.PhotoSentencesPerLang, cfg.PhotoMinPerLangRunes, cfg.MinSummaryTotalRunes)
-	if (policy == "photo-only" && canPhoto) || (policy == "hybrid" && canPhoto) {
-		usePhoto = true
-		outText = news.FormatCaptionForPhoto(*selectedNews, cfg.PhotoCaptionMaxRunes, cfg.PhotoSentencesPerLang, cfg.PhotoMinPerLangRunes)
-	} else {
-		// text-only or hybrid fallback
-		outText = news.FormatNewsWithImage(*selectedNews, cfg.TextSentencesPerLangMin, cfg.TextSentencesPerLangMax)
+	msg := buildMessage(*selectedNews, cfg, ch.Channel)
+	logger.Info("Sending single news", "length", len(msg.Text), "title", selectedNews.Title, "photo", msg.UsePhoto, "reason", msg.Reason, "channel", ch.Name)
+	if a.preview != nil {
+		a.preview.addMessage(msg)
+		return nil
 	}
-	logger.Info("Sending single news", "length", len(outText), "title", selectedNews.Title, "photo", usePhoto)
 
-	var err error
-	if usePhoto {
-		err = telegram.SendPhoto(cfg.TelegramToken, cfg.TelegramChatID, selectedNews.ImageURL, outText)
-	} else {
-		// Allow preview so Telegram can show link thumbnail
-		err = telegram.SendMessageAllowPreview(cfg.TelegramToken, cfg.TelegramChatID, outText)
-	}
+	res, err := a.sendMessage(ctx, msg)
 	if err != nil {
-		logger.Error("Failed to send Telegram message", "error", err)
-		log.Fatalf("Ошибка отправки в Telegram: %v", err)
+		logger.Error("Failed to send Telegram message", "error", err, "channel", ch.Name)
+		return fmt.Errorf("failed to send to Telegram channel %s: %v", ch.Name, err)
 	}
 
 	// Mark as sent
-	hash := cacheAdapter.GenerateNewsHash(selectedNews.Title, selectedNews.Link)
-	if err := cacheAdapter.MarkAsSent(hash, selectedNews.Title, selectedNews.Link, selectedNews.Category, selectedNews.SourceName); err != nil {
+	hash := ch.hash(cacheAdapter, *selectedNews)
+	if err := cacheAdapter.MarkAsSent(sentItem(hash, ch.key, msg, res)); err != nil {
 		logger.Error("Failed to mark news as sent", "error", err)
 	}
 
 	metrics.Global.IncrementTelegramMessagesSent()
-	logger.Info("Single news sent successfully", "title", selectedNews.Title, "hash", hash)
+	logger.Info("Single news sent successfully", "title", selectedNews.Title, "hash", hash, "channel", ch.Name)
+	return nil
 }
 
-// sendMultipleNews отправляет кілька новин, кожну окремим повідомленням (з фото, если есть)
-func sendMultipleNews(newsList []news.News, cfg *config.Config, cacheAdapter CacheAdapter, maxToSend int) {
-	// Filter out duplicates with double check (hash + link)
+// sendMultipleNews отправляет кілька новин у канал, кожну окремим повідомленням (з фото, если есть)
+func (a *App) sendMultipleNews(ctx context.Context, ch *postingChannel, newsList []news.News, maxToSend int) {
+	cfg, cacheAdapter := a.cfg, a.cacheAdapter
+	// Filter out what the channel already got (hash, link and near-duplicate check)
 	var uniqueNews []news.News
 	for _, n := range newsList {
-		hash := cacheAdapter.GenerateNewsHash(n.Title, n.Link)
-
-		// Double protection: check both hash and link
-		if !cacheAdapter.IsAlreadySent(hash) && !cacheAdapter.IsLinkAlreadySent(n.Link) {
+		if !ch.alreadySent(cacheAdapter, n, cfg.MaxFingerprintDistance) {
 			uniqueNews = append(uniqueNews, n)
 		} else {
-			logger.Info("Skipping duplicate news", "title", n.Title, "hash", hash)
+			hash := ch.hash(cacheAdapter, n)
+			logger.Info("Skipping duplicate news", "title", n.Title, "hash", hash, "channel", ch.Name)
 			metrics.Global.IncrementDuplicatesFiltered()
+			a.report.Reject(n.Link, "already sent to "+ch.Name)
+			if a.preview != nil {
+				a.preview.addSkipped(n, "already sent to "+ch.Name+" (hash "+hash+")")
+			}
 		}
 	}
 
 	if len(uniqueNews) == 0 {
-		logger.Warn("All news items are duplicates, nothing to send")
+		logger.Warn("All news items are duplicates, nothing to send", "channel", ch.Name)
 		return
 	}
 
@@ -330,48 +622,43 @@ func sendMultipleNews(newsList []news.News, cfg *config.Config, cacheAdapter Cac
 	if maxToSend > len(uniqueNews) {
 		maxToSend = len(uniqueNews)
 	}
+	batch := uniqueNews[:maxToSend]
 
-	policy := strings.ToLower(strings.TrimSpace(cfg.PostingPolicy))
-	if policy == "" {
-		policy = "hybrid"
+	// Photo posts go out as albums; the rest (and albums that failed) are sent one by one
+	sentCount := 0
+	if cfg.AlbumMode {
+		batch, sentCount = a.sendAlbums(ctx, ch, batch)
 	}
 
 	// Send each item separately using the new format
-	sentCount := 0
-	for i := 0; i < maxToSend; i++ {
-		n := uniqueNews[i]
+	for _, n := range batch {
+		if ctx.Err() != nil {
+			logger.Warn("Shutdown requested, stopping after in-flight send", "sent", sentCount)
+			break
+		}
 
 		// Triple check before sending (paranoid mode to prevent duplicates)
-		hash := cacheAdapter.GenerateNewsHash(n.Title, n.Link)
-		if cacheAdapter.IsAlreadySent(hash) || cacheAdapter.IsLinkAlreadySent(n.Link) {
-			logger.Warn("News became duplicate during sending, skipping", "title", n.Title)
+		hash := ch.hash(cacheAdapter, n)
+		if cacheAdapter.IsAlreadySent(hash) || cacheAdapter.IsLinkAlreadySent(n.Link, ch.key) {
+			logger.Warn("News became duplicate during sending, skipping", "title", n.Title, "channel", ch.Name)
 			continue
 		}
 
-		var outText string
-		usePhoto := false
-		canPhoto := strings.TrimSpace(n.ImageURL) != "" && news.ShouldUsePhoto(n, cfg.PhotoCaptionMaxRunes, cfg.PhotoSentencesPerLang, cfg.PhotoMinPerLangRunes, cfg.MinSummaryTotalRunes)
-		if (policy == "photo-only" && canPhoto) || (policy == "hybrid" && canPhoto) {
-			usePhoto = true
-			outText = news.FormatCaptionForPhoto(n, cfg.PhotoCaptionMaxRunes, cfg.PhotoSentencesPerLang, cfg.PhotoMinPerLangRunes)
-		} else {
-			outText = news.FormatNewsWithImage(n, cfg.TextSentencesPerLangMin, cfg.TextSentencesPerLangMax)
+		msg := buildMessage(n, cfg, ch.Channel)
+		if a.preview != nil {
+			a.preview.addMessage(msg)
+			sentCount++
+			continue
 		}
 
-		var err error
-		if usePhoto {
-			err = telegram.SendPhoto(cfg.TelegramToken, cfg.TelegramChatID, n.ImageURL, outText)
-		} else {
-			// Allow preview so Telegram can show link thumbnail
-			err = telegram.SendMessageAllowPreview(cfg.TelegramToken, cfg.TelegramChatID, outText)
-		}
+		res, err := a.sendMessage(ctx, msg)
 		if err != nil {
-			logger.Error("Failed to send Telegram message", "error", err, "title", n.Title)
+			logger.Error("Failed to send Telegram message", "error", err, "title", n.Title, "channel", ch.Name)
 			continue // Don't fail completely, try next news
 		}
 
 		// Mark as sent immediately after successful send
-		if err := cacheAdapter.MarkAsSent(hash, n.Title, n.Link, n.Category, n.SourceName); err != nil {
+		if err := cacheAdapter.MarkAsSent(sentItem(hash, ch.key, msg, res)); err != nil {
 			logger.Error("Failed to mark news as sent", "error", err, "title", n.Title)
 		} else {
 			logger.Info("News marked as sent", "title", n.Title, "hash", hash)
@@ -381,36 +668,379 @@ func sendMultipleNews(newsList []news.News, cfg *config.Config, cacheAdapter Cac
 		sentCount++
 	}
 
-	logger.Info("Multiple news sent successfully", "count", sentCount, "requested", maxToSend)
+	logger.Info("Multiple news sent successfully", "count", sentCount, "requested", maxToSend, "channel", ch.Name)
 }
 
-// formatSingleNewsMessage адаптирован для саммари
+// sendAlbums posts the photo items as albums of up to 10 and returns the items left for one-by-one
+// sending (text posts, a lone photo, albums that failed) and how many items were posted
+func (a *App) sendAlbums(ctx context.Context, ch *postingChannel, items []news.News) ([]news.News, int) {
+	cfg := a.cfg
+	var photos []outgoingMessage
+	var rest []news.News
+	for _, n := range items {
+		msg := buildMessage(n, cfg, ch.Channel)
+		if msg.UsePhoto {
+			photos = append(photos, msg)
+		} else {
+			rest = append(rest, n)
+		}
+	}
+	if len(photos) < 2 {
+		return items, 0
+	}
+
+	sent := 0
+	for start := 0; start < len(photos); start += telegram.MaxAlbumSize {
+		if ctx.Err() != nil {
+			break
+		}
+		group := photos[start:min(start+telegram.MaxAlbumSize, len(photos))]
+		if len(group) < 2 {
+			rest = append(rest, group[0].News)
+			continue
+		}
+		left, err := a.sendAlbum(ctx, ch, group)
+		if err != nil {
+			logger.Error("Failed to send album, posting its items separately", "error", err, "items", len(group), "channel", ch.Name)
+		}
+		rest = append(rest, left...)
+		sent += len(group) - len(left)
+	}
+	return rest, sent
+}
+
+// sendAlbum posts one album (captions by FormatCaptionForPhoto) and replies to it with a summary of its headlines.
+// Items whose image cannot be downloaded or converted are left out and returned with the image marked as
+// rejected, so they are posted as text; if fewer than two photos remain, or on error, every item is returned.
+func (a *App) sendAlbum(ctx context.Context, ch *postingChannel, group []outgoingMessage) ([]news.News, error) {
+	cfg, cacheAdapter := a.cfg, a.cacheAdapter
+	summary := outgoingMessage{
+		News:      news.News{Title: fmt.Sprintf("Album summary (%d news)", len(group))},
+		Channel:   ch.Name,
+		ChatID:    ch.ChatID,
+		NoPreview: true,
+		Text:      formatAlbumSummary(group, news.Layout(ch.Layout)),
+		Reason:    "ALBUM_MODE: headlines of the album, posted as a reply to it",
+	}
+	if a.preview != nil {
+		for i, m := range group {
+			m.Album = true
+			m.Reason = fmt.Sprintf("ALBUM_MODE: item %d of %d, %s", i+1, len(group), m.Reason)
+			a.preview.addMessage(m)
+		}
+		a.preview.addMessage(summary)
+		return nil, nil
+	}
+
+	all := func() []news.News {
+		out := make([]news.News, len(group))
+		for i, m := range group {
+			out[i] = m.News
+		}
+		return out
+	}
+
+	// Downloads can still be interrupted by shutdown; only the send itself is not
+	var items []telegram.InputMedia
+	var usable []outgoingMessage
+	var unusable []news.News
+	for _, m := range group {
+		photo, file, err := a.photoInput(ctx, m.News)
+		if err != nil {
+			if ctx.Err() != nil {
+				return all(), ctx.Err()
+			}
+			logger.Warn("Image unusable, leaving the item out of the album", "error", err, "title", m.News.Title, "channel", ch.Name)
+			n := m.News
+			n.Image = &media.Check{URL: n.ImageURL, Reason: err.Error()}
+			unusable = append(unusable, n)
+			continue
+		}
+		items = append(items, telegram.InputMedia{Media: photo, File: file, Caption: m.Text})
+		usable = append(usable, m)
+	}
+	if len(usable) < 2 {
+		for _, m := range usable {
+			unusable = append(unusable, m.News)
+		}
+		return unusable, nil
+	}
+	group = usable
+	summary.News.Title = fmt.Sprintf("Album summary (%d news)", len(group))
+	summary.Text = formatAlbumSummary(group, news.Layout(ch.Layout))
+
+	// Like sendMessage: a started send is not cut off by cancellation
+	ctx = context.WithoutCancel(ctx)
+	results, err := a.sender.SendMediaGroup(ctx, ch.ChatID, items, telegram.Options{
+		Silent:         cfg.TelegramSilent,
+		ProtectContent: cfg.TelegramProtectContent,
+	})
+	if err != nil {
+		return append(all(), unusable...), err
+	}
+
+	for i, m := range group {
+		if items[i].File != nil {
+			a.rememberPhoto(m.News.ImageURL, results[i].PhotoFileID)
+		}
+		hash := ch.hash(cacheAdapter, m.News)
+		if err := cacheAdapter.MarkAsSent(sentItem(hash, ch.key, m, results[i])); err != nil {
+			logger.Error("Failed to mark news as sent", "error", err, "title", m.News.Title)
+		}
+		metrics.Global.IncrementTelegramMessagesSent()
+	}
+	logger.Info("Album sent", "items", len(group), "channel", ch.Name)
+
+	summary.ReplyTo = results[0].MessageID
+	if _, err := a.sendMessage(ctx, summary); err != nil {
+		// The album itself is posted and marked; only the headline list is missing
+		logger.Error("Failed to send album summary", "error", err, "channel", ch.Name)
+	}
+	return unusable, nil
+}
+
+// sendDigest posts up to MaxItems new items of the channel as one "Новини Данії" message, split into
+// several parts when longer than Telegram allows. Every item is marked as sent with the part it appears in.
+func (a *App) sendDigest(ctx context.Context, ch *postingChannel, newsList []news.News) error {
+	cfg, cacheAdapter := a.cfg, a.cacheAdapter
+
+	var items []news.News
+	for _, n := range newsList {
+		if len(items) >= ch.MaxItems {
+			break
+		}
+		if ch.alreadySent(cacheAdapter, n, cfg.MaxFingerprintDistance) {
+			hash := ch.hash(cacheAdapter, n)
+			logger.Info("Skipping duplicate news", "title", n.Title, "hash", hash, "channel", ch.Name)
+			metrics.Global.IncrementDuplicatesFiltered()
+			a.report.Reject(n.Link, "already sent to "+ch.Name)
+			if a.preview != nil {
+				a.preview.addSkipped(n, "already sent to "+ch.Name+" (hash "+hash+")")
+			}
+			continue
+		}
+		n.Layout = news.Layout(ch.Layout)
+		items = append(items, n)
+	}
+	if len(items) == 0 {
+		logger.Warn("All news items are duplicates, nothing to send", "channel", ch.Name)
+		return nil
+	}
+
+	var text string
+	if len(items) == 1 {
+		text = formatSingleNewsMessage(items[0], 1)
+	} else {
+		text = formatNewsMessage(items, len(items), news.Layout(ch.Layout))
+	}
+	parts := telegram.SplitHTML(text, telegram.MaxMessageLength)
+	logger.Info("Sending digest", "items", len(items), "parts", len(parts), "channel", ch.Name)
+
+	marked := make(map[string]bool)
+	for i, part := range parts {
+		msg := outgoingMessage{
+			News:      news.News{Title: fmt.Sprintf("Digest part %d/%d (%d news)", i+1, len(parts), len(items))},
+			Channel:   ch.Name,
+			ChatID:    ch.ChatID,
+			NoPreview: true,
+			Text:      part,
+			Reason:    fmt.Sprintf("BOT_MODE=digest: part %d of %d", i+1, len(parts)),
+		}
+		if a.preview != nil {
+			a.preview.addMessage(msg)
+			continue
+		}
+
+		res, err := a.sendMessage(ctx, msg)
+		if err != nil {
+			// Items of earlier parts stay marked; the rest is tried again next run
+			logger.Error("Failed to send digest part", "error", err, "part", i+1, "channel", ch.Name)
+			return fmt.Errorf("failed to send digest to Telegram channel %s: %v", ch.Name, err)
+		}
+		metrics.Global.IncrementTelegramMessagesSent()
+
+		for _, n := range items {
+			if marked[n.Link] || !partHasItem(part, n) {
+				continue
+			}
+			marked[n.Link] = true
+			msg.News = n
+			if err := cacheAdapter.MarkAsSent(sentItem(ch.hash(cacheAdapter, n), ch.key, msg, res)); err != nil {
+				logger.Error("Failed to mark news as sent", "error", err, "title", n.Title)
+			}
+		}
+	}
+
+	logger.Info("Digest sent successfully", "items", len(items), "parts", len(parts), "channel", ch.Name)
+	return nil
+}
+
+// partHasItem reports whether a digest part holds n; the whole href is matched, so a link that is
+// a prefix of another item's link (https://dr.dk/a and https://dr.dk/a1) does not count
+func partHasItem(part string, n news.News) bool {
+	return strings.Contains(part, `href="`+html.EscapeString(n.Link)+`"`)
+}
+
+// sendMessage posts a prepared message as a photo with caption or as text.
+// If the image cannot be downloaded or converted, the item is posted as text instead.
+// A send that has started is not cut off by cancellation of ctx, so a message is never half-posted and left unmarked.
+func (a *App) sendMessage(ctx context.Context, msg outgoingMessage) (*telegram.Result, error) {
+	cfg := a.cfg
+	ctx = context.WithoutCancel(ctx)
+	opts := telegram.Options{
+		Silent:         cfg.TelegramSilent,
+		ProtectContent: cfg.TelegramProtectContent,
+		ReplyTo:        msg.ReplyTo,
+	}
+	if msg.UsePhoto && msg.ReplyTo == 0 {
+		res, err := a.sendPhoto(ctx, msg, opts)
+		if !errors.Is(err, errPhotoUnusable) {
+			return res, err
+		}
+		logger.Warn("Image unusable, posting as text", "error", err, "title", msg.News.Title, "channel", msg.Channel)
+		msg.Text = news.FormatNewsWithImage(msg.News, cfg.TextSentencesPerLangMin, cfg.TextSentencesPerLangMax)
+	}
+	// Allow preview so Telegram can show link thumbnail (not for replies, digests and album summaries)
+	opts.LinkPreview = msg.ReplyTo == 0 && !msg.NoPreview
+	return a.sender.SendMessage(ctx, msg.ChatID, msg.Text, opts)
+}
+
+// errPhotoUnusable means the article image could not be downloaded or converted for Telegram
+var errPhotoUnusable = errors.New("image unusable")
+
+// photoInput returns what to send as the photo of n: the file_id of an earlier upload, or the image
+// downloaded, checked and converted to an upload (already done if the image was validated).
+// With UPLOAD_PHOTOS=false, and for formats we cannot convert (AVIF), the URL is passed to Telegram as is.
+func (a *App) photoInput(ctx context.Context, n news.News) (string, *telegram.InputFile, error) {
+	imageURL := n.ImageURL
+	if !a.cfg.UploadPhotos || (n.Image != nil && n.Image.ByURL) {
+		return imageURL, nil, nil
+	}
+	if fileID, err := a.photos.GetPhotoFileID(imageURL); err != nil {
+		logger.Warn("Failed to read photo cache", "error", err)
+	} else if fileID != "" {
+		return fileID, nil, nil
+	}
+
+	var img *media.Image
+	if n.Image != nil {
+		img = n.Image.Image
+	}
+	if img == nil {
+		var err error
+		if img, err = media.Fetch(ctx, imageURL); errors.Is(err, media.ErrUnsupportedFormat) {
+			logger.Info("Image format cannot be converted, passing the URL to Telegram", "url", imageURL, "error", err)
+			return imageURL, nil, nil
+		} else if err != nil {
+			return "", nil, fmt.Errorf("%w: %s: %v", errPhotoUnusable, imageURL, err)
+		}
+	}
+	if img.Transcoded {
+		logger.Info("Image converted for upload", "url", imageURL, "from", img.SourceFormat, "width", img.Width, "height", img.Height, "bytes", len(img.Data))
+	}
+	return "", &telegram.InputFile{Name: "photo." + img.Format, Data: img.Data}, nil
+}
+
+// sendPhoto posts msg as a photo with caption, uploading the image unless Telegram already has it.
+// A cached file_id that Telegram rejects is dropped and the image uploaded again;
+// an image URL Telegram rejects in place of an upload (AVIF) makes the item go out as text.
+func (a *App) sendPhoto(ctx context.Context, msg outgoingMessage, opts telegram.Options) (*telegram.Result, error) {
+	imageURL := msg.News.ImageURL
+	photo, file, err := a.photoInput(ctx, msg.News)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		res, err := a.sender.SendPhoto(ctx, msg.ChatID, photo, msg.Text, opts)
+		if err == nil || !telegram.IsPermanent(err) {
+			return res, err
+		}
+		if photo == imageURL {
+			if a.cfg.UploadPhotos {
+				return nil, fmt.Errorf("%w: %s: %v", errPhotoUnusable, imageURL, err)
+			}
+			return res, err
+		}
+		logger.Warn("Cached photo rejected by Telegram, uploading again", "error", err, "url", imageURL)
+		if err := a.photos.SetPhotoFileID(imageURL, ""); err != nil {
+			logger.Warn("Failed to update photo cache", "error", err)
+		}
+		if _, file, err = a.photoInput(ctx, msg.News); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := a.sender.SendPhotoFile(ctx, msg.ChatID, *file, msg.Text, opts)
+	if err != nil {
+		return nil, err
+	}
+	a.rememberPhoto(imageURL, res.PhotoFileID)
+	return res, nil
+}
+
+// rememberPhoto caches the file_id Telegram assigned to an uploaded image
+func (a *App) rememberPhoto(imageURL, fileID string) {
+	if fileID == "" {
+		return
+	}
+	if err := a.photos.SetPhotoFileID(imageURL, fileID); err != nil {
+		logger.Warn("Failed to update photo cache", "error", err)
+	}
+}
+
+// formatAlbumSummary lists the headlines of an album with links to the articles
+func formatAlbumSummary(group []outgoingMessage, layout news.Layout) string {
+	var b strings.Builder
+	switch layout {
+	case news.LayoutUkrainian:
+		b.WriteString("📸 <b>Новини дня</b>\n\n")
+	case news.LayoutDanish:
+		b.WriteString("📸 <b>Dagens nyheder</b>\n\n")
+	default:
+		b.WriteString("📸 <b>Dagens nyheder · Новини дня</b>\n\n")
+	}
+	for i, m := range group {
+		n := m.News
+		title := n.Title
+		if layout == news.LayoutUkrainian && strings.TrimSpace(n.TitleUkrainian) != "" {
+			title = n.TitleUkrainian
+		}
+		b.WriteString(fmt.Sprintf("%d. <a href=\"%s\">%s</a>", i+1, html.EscapeString(n.Link), html.EscapeString(strings.TrimSpace(title))))
+		if n.SourceName != "" {
+			b.WriteString(" — " + html.EscapeString(n.SourceName))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatSingleNewsMessage адаптирован для саммари (digest of one item; languages and headers per n.Layout)
 func formatSingleNewsMessage(n news.News, number int) string {
 	var b strings.Builder
 
 	// Красивый заголовок
-	b.WriteString("🇩🇰 <b>Danish News</b> 🇺🇦\n")
+	b.WriteString(n.Layout.Pick("🇩🇰 <b>Danish News</b> 🇺🇦", "🇩🇰 <b>Danske nyheder</b>", "🇺🇦 <b>Новини Данії</b>") + "\n")
 	b.WriteString("━━━━━━━━━━━━━━━\n\n")
 
 	// Определяем категорию и эмодзи
 	emoji := "📰"
-	categoryText := "🇩🇰 <b>НОВИНИ ДАНІЇ - Стисло!</b>"
+	categoryText := n.Layout.Pick("🇩🇰 <b>НОВИНИ ДАНІЇ - Стисло!</b>", "🇩🇰 <b>NYHEDER FRA DANMARK - Kort!</b>", "🇩🇰 <b>НОВИНИ ДАНІЇ - Стисло!</b>")
 
 	if n.Category == "ukraine" {
 		emoji = "🔥"
-		categoryText = "🇺🇦 <b>УКРАЇНА В ДАНІЇ</b>"
+		categoryText = n.Layout.Pick("🇺🇦 <b>УКРАЇНА В ДАНІЇ</b>", "🇺🇦 <b>UKRAINE I DANMARK</b>", "🇺🇦 <b>УКРАЇНА В ДАНІЇ</b>")
 	}
 
 	b.WriteString(categoryText + "\n\n")
 
 	// Заголовок новости с ссылкой
-	b.WriteString(fmt.Sprintf("%s <a href=\"%s\">%s</a>\n\n", emoji, n.Link, n.Title))
+	b.WriteString(fmt.Sprintf("%s <a href=\"%s\">%s</a>\n\n", emoji, html.EscapeString(n.Link), html.EscapeString(n.Title)))
 
-	if n.SummaryUkrainian != "" {
-		b.WriteString("🇺🇦 <i>" + limitText(n.SummaryUkrainian, 1000) + "</i>\n\n")
+	if n.SummaryUkrainian != "" && n.Layout != news.LayoutDanish {
+		b.WriteString("🇺🇦 <i>" + html.EscapeString(limitText(n.SummaryUkrainian, 1000)) + "</i>\n\n")
 	}
-	if n.SummaryDanish != "" {
-		b.WriteString("🇩🇰 " + limitText(n.SummaryDanish, 1000) + "\n\n")
+	if n.SummaryDanish != "" && n.Layout != news.LayoutUkrainian {
+		b.WriteString("🇩🇰 " + html.EscapeString(limitText(n.SummaryDanish, 1000)) + "\n\n")
 	}
 
 	b.WriteString("━━━━━━━━━━━━━━━\n")
@@ -483,3 +1113,88 @@ func isIrrelevantSentence(sentence string) bool {
 
 	return false
 }
+
+// sentItem is the sent-news record for a message posted to a channel stored under key
+func sentItem(hash, key string, msg outgoingMessage, res *telegram.Result) storage.SentNewsItem {
+	n := msg.News
+	item := storage.SentNewsItem{
+		Hash:        hash,
+		Title:       n.Title,
+		Link:        n.Link,
+		Category:    n.Category,
+		Source:      n.SourceName,
+		Fingerprint: n.Fingerprint,
+		ChatID:      msg.ChatID,
+		Channel:     key,
+		Content:     n.Description,
+	}
+	if res != nil {
+		item.MessageID = res.MessageID
+		if res.ChatID != 0 {
+			// numeric id keeps working if the channel's @username changes
+			item.ChatID = strconv.FormatInt(res.ChatID, 10)
+		}
+	}
+	return item
+}
+
+// sendUpdates posts "Оновлення" replies under stories of the channel that got substantially updated articles.
+// Change summaries share MAX_GEMINI_REQUESTS with translation; a dry run detects updates without summarizing them.
+func (a *App) sendUpdates(ctx context.Context, ch *postingChannel, items []*rss.FeedItem) {
+	cfg := a.cfg
+	opts := news.UpdateOptions{
+		MinNovelty:             float64(cfg.UpdateMinNoveltyPercent) / 100,
+		MaxUpdates:             cfg.MaxUpdatesPerRun,
+		MaxFingerprintDistance: cfg.MaxFingerprintDistance,
+		SkipSummaries:          a.preview != nil,
+	}
+	if cfg.MaxGeminiRequests > 0 && a.preview == nil {
+		used := a.limiter.RunUsage()
+		opts.MaxSummaries = cfg.MaxGeminiRequests - used.Gemini
+		if opts.MaxSummaries <= 0 {
+			logger.Warn("AI request budget used up, skipping story updates", "channel", ch.Name, "used", used.Gemini)
+			return
+		}
+	}
+	updates, err := news.DetectUpdates(ctx, items, ch.sent, opts)
+	if err != nil {
+		logger.Error("Failed to detect story updates", "error", err)
+		return
+	}
+
+	for _, u := range updates {
+		if opts.SkipSummaries {
+			u.Summary = "(dry run: AI summary of the changes not generated)"
+		}
+		// The update gets its own record so it is neither re-posted nor mistaken for new facts later
+		hash := storage.ScopedHash(a.cacheAdapter.GenerateNewsHash("update: "+u.News.Title+" "+u.News.Description, u.News.Link), ch.key)
+		if a.cacheAdapter.IsAlreadySent(hash) {
+			continue
+		}
+		msg := outgoingMessage{
+			News:    u.News,
+			Channel: ch.Name,
+			ChatID:  ch.ChatID,
+			ReplyTo: u.Original.MessageID,
+			Text:    news.FormatUpdate(u),
+			Reason:  fmt.Sprintf("update of %q (%.0f%% new words)", u.Original.Title, u.Novelty*100),
+		}
+		if a.preview != nil {
+			a.preview.addMessage(msg)
+			continue
+		}
+
+		res, err := a.sendMessage(ctx, msg)
+		if err != nil {
+			logger.Error("Failed to send story update", "error", err, "title", u.News.Title, "channel", ch.Name)
+			continue
+		}
+		record := sentItem(hash, ch.key, msg, res)
+		record.Category = "update"
+		if err := a.cacheAdapter.MarkAsSent(record); err != nil {
+			logger.Error("Failed to mark update as sent", "error", err, "title", u.News.Title)
+		}
+		metrics.Global.IncrementTelegramMessagesSent()
+		logger.Info("Story update sent", "title", u.News.Title, "reply_to", u.Original.MessageID, "channel", ch.Name)
+	}
+}
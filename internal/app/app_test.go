@@ -0,0 +1,186 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deusflow/News/internal/config"
+	"github.com/deusflow/News/internal/logger"
+	"github.com/deusflow/News/internal/news"
+	"github.com/deusflow/News/internal/storage"
+	"github.com/deusflow/News/internal/telegram"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+func TestPartHasItem(t *testing.T) {
+	part := formatSingleNews(news.News{Title: "A1", Link: "https://dr.dk/a1?x=1&y=2"}, 1)
+	if !partHasItem(part, news.News{Link: "https://dr.dk/a1?x=1&y=2"}) {
+		t.Errorf("item not found in its own part:\n%s", part)
+	}
+	if partHasItem(part, news.News{Link: "https://dr.dk/a"}) {
+		t.Errorf("a prefix of another link matched:\n%s", part)
+	}
+}
+
+// fakeSender records messages and fails those whose text contains fail
+type fakeSender struct {
+	fail   string
+	texts  []string
+	albums [][]telegram.InputMedia
+}
+
+func (s *fakeSender) SendMessage(ctx context.Context, chatID, text string, opts telegram.Options) (*telegram.Result, error) {
+	s.texts = append(s.texts, text)
+	if s.fail != "" && strings.Contains(text, s.fail) {
+		return nil, &telegram.Error{HTTPStatus: 400, Code: 400, Description: "Bad Request: chat not found"}
+	}
+	return &telegram.Result{MessageID: int64(100 + len(s.texts)), ChatID: -100123}, nil
+}
+
+func (s *fakeSender) SendPhoto(ctx context.Context, chatID, photoURL, caption string, opts telegram.Options) (*telegram.Result, error) {
+	return nil, errors.New("not expected")
+}
+
+func (s *fakeSender) SendPhotoFile(ctx context.Context, chatID string, file telegram.InputFile, caption string, opts telegram.Options) (*telegram.Result, error) {
+	return nil, errors.New("not expected")
+}
+
+func (s *fakeSender) SendMediaGroup(ctx context.Context, chatID string, media []telegram.InputMedia, opts telegram.Options) ([]*telegram.Result, error) {
+	s.albums = append(s.albums, media)
+	results := make([]*telegram.Result, len(media))
+	for i := range media {
+		results[i] = &telegram.Result{MessageID: int64(200 + i), PhotoFileID: fmt.Sprintf("file%d", i)}
+	}
+	return results, nil
+}
+
+func TestSendMultipleNews_MarksOnlySentItems(t *testing.T) {
+	cache := &FileCacheAdapter{cache: storage.NewFileCache(filepath.Join(t.TempDir(), "sent.json"), 48)}
+	sender := &fakeSender{fail: "Broen"}
+	a := &App{cfg: &config.Config{}, cacheAdapter: cache, sender: sender}
+	ch := &postingChannel{Channel: config.Channel{Name: "main", ChatID: "@news", PostingPolicy: "text-only"}, key: "main"}
+
+	items := []news.News{
+		{Title: "Skolerne får flere lærere", Link: "https://dr.dk/skole", SummaryDanish: "Flere lærere.", SummaryUkrainian: "Більше вчителів."},
+		{Title: "Broen er lukket", Link: "https://dr.dk/bro", SummaryDanish: "Broen er lukket.", SummaryUkrainian: "Міст закрито."},
+		{Title: "Ny metro i Aarhus", Link: "https://dr.dk/metro", SummaryDanish: "Ny metro.", SummaryUkrainian: "Нове метро."},
+	}
+	a.sendMultipleNews(context.Background(), ch, items, 5)
+
+	// A failed send does not stop the others and is not marked, so the next run retries it
+	if len(sender.texts) != 3 {
+		t.Fatalf("expected 3 send attempts, got %d", len(sender.texts))
+	}
+	for _, n := range items {
+		sent := cache.IsAlreadySent(ch.hash(cache, n))
+		if want := n.Link != "https://dr.dk/bro"; sent != want {
+			t.Errorf("%s: marked as sent = %v, want %v", n.Link, sent, want)
+		}
+	}
+	records := cache.SentSince(time.Now().Add(-time.Minute))
+	if len(records) != 2 || records[0].MessageID == 0 || records[0].ChatID != "-100123" || records[0].Channel != "main" {
+		t.Errorf("unexpected sent records: %+v", records)
+	}
+
+	// Next run: only the failed item is sent again
+	sender.fail, sender.texts = "", nil
+	a.sendMultipleNews(context.Background(), ch, items, 5)
+	if len(sender.texts) != 1 || !strings.Contains(sender.texts[0], "Broen") {
+		t.Errorf("expected only the failed item to be retried, got %d sends", len(sender.texts))
+	}
+}
+
+func TestLoadChannels_HistoryFollowsName(t *testing.T) {
+	cache := &FileCacheAdapter{cache: storage.NewFileCache(filepath.Join(t.TempDir(), "sent.json"), 48)}
+	cache.MarkAsSent(storage.SentNewsItem{Hash: "a", Link: "https://dr.dk/old", Channel: ""})
+	cache.MarkAsSent(storage.SentNewsItem{Hash: "b", Link: "https://dr.dk/family", Channel: "family"})
+
+	path := filepath.Join(t.TempDir(), "channels.yaml")
+	// family is listed first; the history still goes by name and legacy_history
+	if err := os.WriteFile(path, []byte(`channels:
+  - name: family
+    chat_id: "@family"
+  - name: main
+    chat_id: "@news"
+    legacy_history: true
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	channels, err := loadChannels(&config.Config{ChannelsConfigPath: path}, cache, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	family, main := channels[0], channels[1]
+	if family.key != "family" || len(family.sent) != 1 || family.sent[0].Link != "https://dr.dk/family" {
+		t.Errorf("family: key %q, sent %+v", family.key, family.sent)
+	}
+	if main.key != "" || len(main.sent) != 1 || main.sent[0].Link != "https://dr.dk/old" {
+		t.Errorf("main: key %q, sent %+v", main.key, main.sent)
+	}
+}
+
+func TestSendAlbum_DropsUnusableImage(t *testing.T) {
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, image.NewGray(image.Rect(0, 0, 40, 30)), nil); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(jpg.Bytes())
+	}))
+	defer srv.Close()
+
+	cache := &FileCacheAdapter{cache: storage.NewFileCache(filepath.Join(t.TempDir(), "sent.json"), 48)}
+	sender := &fakeSender{}
+	a := &App{
+		cfg:          &config.Config{UploadPhotos: true},
+		cacheAdapter: cache,
+		sender:       sender,
+		photos:       storage.NewFilePhotoCache(filepath.Join(t.TempDir(), "photos.json")),
+	}
+	ch := &postingChannel{Channel: config.Channel{Name: "main", ChatID: "@news"}, key: "main"}
+	var group []outgoingMessage
+	for _, name := range []string{"a", "broken", "b"} {
+		n := news.News{Title: "Story " + name, Link: "https://dr.dk/" + name, ImageURL: srv.URL + "/" + name + ".jpg"}
+		group = append(group, outgoingMessage{News: n, ChatID: ch.ChatID, UsePhoto: true, Text: n.Title})
+	}
+
+	left, err := a.sendAlbum(context.Background(), ch, group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.albums) != 1 || len(sender.albums[0]) != 2 {
+		t.Fatalf("expected one album of the 2 usable photos, got %v", sender.albums)
+	}
+	if len(left) != 1 || left[0].Link != "https://dr.dk/broken" {
+		t.Fatalf("expected the broken item back, got %+v", left)
+	}
+	if msg := buildMessage(left[0], a.cfg, ch.Channel); msg.UsePhoto {
+		t.Errorf("item with a broken image should go out as text: %s", msg.Reason)
+	}
+	if !cache.IsAlreadySent(ch.hash(cache, group[0].News)) || cache.IsAlreadySent(ch.hash(cache, left[0])) {
+		t.Errorf("only album items should be marked as sent")
+	}
+	if len(sender.texts) != 1 || strings.Contains(sender.texts[0], "Story broken") {
+		t.Errorf("album summary should list only the album items: %q", sender.texts)
+	}
+}
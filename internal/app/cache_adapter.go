@@ -1,6 +1,9 @@
 package app
 
 import (
+	"log"
+	"time"
+
 	"github.com/deusflow/News/internal/storage"
 )
 
@@ -8,8 +11,9 @@ import (
 type CacheAdapter interface {
 	GenerateNewsHash(title, link string) string
 	IsAlreadySent(hash string) bool
-	IsLinkAlreadySent(link string) bool
-	MarkAsSent(hash, title, link, category, source string) error
+	IsLinkAlreadySent(link, channel string) bool
+	MarkAsSent(item storage.SentNewsItem) error
+	SentSince(since time.Time) []storage.SentNewsItem
 }
 
 // FileCacheAdapter wraps FileCache to implement CacheAdapter
@@ -25,17 +29,21 @@ func (f *FileCacheAdapter) IsAlreadySent(hash string) bool {
 	return f.cache.IsAlreadySent(hash)
 }
 
-func (f *FileCacheAdapter) IsLinkAlreadySent(link string) bool {
+func (f *FileCacheAdapter) IsLinkAlreadySent(link, channel string) bool {
 	// File cache doesn't have direct link check, so generate hash from link
 	// This is a simplified check - in practice, file cache checks by hash only
 	return false
 }
 
-func (f *FileCacheAdapter) MarkAsSent(hash, title, link, category, source string) error {
-	f.cache.MarkAsSent(hash, title, link, category, source)
+func (f *FileCacheAdapter) MarkAsSent(item storage.SentNewsItem) error {
+	f.cache.MarkAsSent(item)
 	return nil
 }
 
+func (f *FileCacheAdapter) SentSince(since time.Time) []storage.SentNewsItem {
+	return f.cache.SentSince(since)
+}
+
 // PostgresCacheAdapter wraps PostgresCache to implement CacheAdapter
 type PostgresCacheAdapter struct {
 	cache *storage.PostgresCache
@@ -49,10 +57,19 @@ func (p *PostgresCacheAdapter) IsAlreadySent(hash string) bool {
 	return p.cache.IsAlreadySent(hash)
 }
 
-func (p *PostgresCacheAdapter) IsLinkAlreadySent(link string) bool {
-	return p.cache.IsLinkAlreadySent(link)
+func (p *PostgresCacheAdapter) IsLinkAlreadySent(link, channel string) bool {
+	return p.cache.IsLinkAlreadySent(link, channel)
+}
+
+func (p *PostgresCacheAdapter) MarkAsSent(item storage.SentNewsItem) error {
+	return p.cache.MarkAsSent(item)
 }
 
-func (p *PostgresCacheAdapter) MarkAsSent(hash, title, link, category, source string) error {
-	return p.cache.MarkAsSent(hash, title, link, category, source)
+func (p *PostgresCacheAdapter) SentSince(since time.Time) []storage.SentNewsItem {
+	items, err := p.cache.SentSince(since)
+	if err != nil {
+		// Same as the other checks: a database hiccup must not stop posting
+		log.Printf("⚠️ Error loading recently sent news: %v", err)
+	}
+	return items
 }
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"time"
+
+	"github.com/deusflow/News/internal/config"
+	"github.com/deusflow/News/internal/news"
+	"github.com/deusflow/News/internal/storage"
+)
+
+// postingChannel is a configured channel with its sent-news scope
+type postingChannel struct {
+	config.Channel
+	key  string           // sent-news records are stored under this key: the name, or "" for the legacy_history channel
+	sent []news.SentStory // stories posted to the channel within DUPLICATE_WINDOW_HOURS
+}
+
+// loadChannels reads the channels config and attaches to every channel what it got since since
+func loadChannels(cfg *config.Config, cacheAdapter CacheAdapter, since time.Time) ([]*postingChannel, error) {
+	channels, err := cfg.Channels()
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*postingChannel, len(channels))
+	result := make([]*postingChannel, 0, len(channels))
+	for _, ch := range channels {
+		pc := &postingChannel{Channel: ch, key: ch.Name}
+		if ch.LegacyHistory {
+			// Keeps the records written before channels existed, wherever it is in the list
+			pc.key = ""
+		}
+		byKey[pc.key] = pc
+		result = append(result, pc)
+	}
+
+	for _, it := range cacheAdapter.SentSince(since) {
+		if pc := byKey[it.Channel]; pc != nil {
+			pc.sent = append(pc.sent, sentStory(it))
+		}
+	}
+	return result, nil
+}
+
+func sentStory(it storage.SentNewsItem) news.SentStory {
+	return news.SentStory{
+		Title: it.Title, Link: it.Link, Fingerprint: it.Fingerprint, SentAt: it.SentAt,
+		MessageID: it.MessageID, Content: it.Content,
+	}
+}
+
+// sentEverywhere returns the stories every channel already got; only those can be dropped
+// before summarizing, anything else may still be new to some channel
+func sentEverywhere(channels []*postingChannel) []news.SentStory {
+	if len(channels) == 1 {
+		return channels[0].sent
+	}
+	counts := make(map[string]int)
+	for _, ch := range channels {
+		seen := make(map[string]bool)
+		for _, s := range ch.sent {
+			if !seen[s.Link] {
+				seen[s.Link] = true
+				counts[s.Link]++
+			}
+		}
+	}
+	var out []news.SentStory
+	for _, s := range channels[0].sent {
+		if counts[s.Link] == len(channels) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// targets lets every channel pick its own candidates (see news.Options.Targets)
+func targets(channels []*postingChannel) []news.Target {
+	out := make([]news.Target, 0, len(channels))
+	for _, ch := range channels {
+		out = append(out, news.Target{Limit: ch.MaxItems, Allows: ch.Allows, Sent: ch.sent})
+	}
+	return out
+}
+
+// selectFor keeps the items the channel accepts by category and score
+func (ch *postingChannel) selectFor(newsList []news.News) []news.News {
+	var out []news.News
+	for _, n := range newsList {
+		if ch.Allows(n.Category, n.Score) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// hash is the channel-scoped sent-news hash of n
+func (ch *postingChannel) hash(cacheAdapter CacheAdapter, n news.News) string {
+	return storage.ScopedHash(cacheAdapter.GenerateNewsHash(n.Title, n.Link), ch.key)
+}
+
+// alreadySent reports whether the channel got n already (hash, link or a near-duplicate story)
+func (ch *postingChannel) alreadySent(cacheAdapter CacheAdapter, n news.News, maxDistance int) bool {
+	if cacheAdapter.IsAlreadySent(ch.hash(cacheAdapter, n)) || cacheAdapter.IsLinkAlreadySent(n.Link, ch.key) {
+		return true
+	}
+	return news.SentDuplicate(n, ch.sent, maxDistance) != nil
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/deusflow/News/internal/logger"
+	"github.com/deusflow/News/internal/metrics"
+	"github.com/deusflow/News/internal/schedule"
+)
+
+// RunDaemon keeps the process alive and runs the pipeline on the configured cron schedule.
+// Clients and caches are shared between runs. On SIGINT/SIGTERM the daemon stops waiting,
+// lets an active run finish the message in flight, saves caches and exits.
+func RunDaemon() {
+	cfg := loadConfig(false)
+
+	loc, err := time.LoadLocation(cfg.ScheduleTimezone)
+	if err != nil {
+		log.Fatalf("Ошибка SCHEDULE_TZ: %v", err)
+	}
+	sched, err := schedule.New(cfg.Schedule, cfg.QuietHours, loc)
+	if err != nil {
+		log.Fatalf("Ошибка розкладу: %v", err)
+	}
+
+	a, err := New(cfg)
+	if err != nil {
+		log.Fatalf("Ошибка инициализации: %v", err)
+	}
+	defer a.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info("Daemon started", "schedule", cfg.Schedule, "timezone", loc.String(), "quiet_hours", cfg.QuietHours)
+	if cfg.RunOnStart {
+		a.runScheduled(ctx)
+	}
+
+	for ctx.Err() == nil {
+		next := sched.Next(time.Now())
+		if next.IsZero() {
+			logger.Error("Schedule never fires, stopping daemon", "schedule", cfg.Schedule)
+			return
+		}
+		logger.Info("Next run scheduled", "at", next.Format(time.RFC3339), "in", time.Until(next).Round(time.Second).String())
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+			a.runScheduled(ctx)
+		}
+	}
+	logger.Info("Shutdown signal received, daemon stopped")
+}
+
+// runScheduled runs the pipeline once; failures are logged and never stop the daemon
+func (a *App) runScheduled(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Pipeline run panicked", "panic", r)
+			metrics.Global.SetError(fmt.Sprintf("pipeline panic: %v", r))
+		}
+	}()
+
+	started := time.Now()
+	if err := a.RunOnce(ctx); err != nil {
+		logger.Error("Pipeline run failed", "error", err, "duration", time.Since(started).String())
+		metrics.Global.SetError(err.Error())
+		return
+	}
+	logger.Info("Pipeline run finished", "duration", time.Since(started).String())
+}
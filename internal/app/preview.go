@@ -0,0 +1,191 @@
+package app
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/deusflow/News/internal/config"
+	"github.com/deusflow/News/internal/news"
+	"github.com/deusflow/News/internal/ratelimit"
+	"github.com/deusflow/News/internal/rss"
+)
+
+// outgoingMessage is exactly what gets posted to Telegram for one news item
+type outgoingMessage struct {
+	News      news.News
+	Channel   string // channel name
+	ChatID    string
+	UsePhoto  bool
+	NoPreview bool   // no link preview (digest parts, album summaries)
+	Album     bool   // item of an album (sendMediaGroup)
+	ReplyTo   int64  // message_id to reply to (follow-up updates)
+	Text      string // HTML payload: photo caption or message text
+	Reason    string // why photo or text was chosen
+}
+
+// buildMessage applies the channel's posting policy and language layout to a news item and renders the Telegram payload
+func buildMessage(n news.News, cfg *config.Config, ch config.Channel) outgoingMessage {
+	policy := strings.ToLower(strings.TrimSpace(ch.PostingPolicy))
+	if policy == "" {
+		policy = "hybrid"
+	}
+
+	n.Layout = news.Layout(ch.Layout)
+	msg := outgoingMessage{News: n, Channel: ch.Name, ChatID: ch.ChatID}
+	switch {
+	case policy != "hybrid" && policy != "photo-only":
+		msg.Reason = fmt.Sprintf("POSTING_POLICY=%s always sends text", policy)
+	case strings.TrimSpace(n.ImageURL) == "":
+		msg.Reason = "no image found for this article"
+	default:
+		ok, why := news.PhotoDecision(n, cfg.PhotoCaptionMaxRunes, cfg.PhotoSentencesPerLang, cfg.PhotoMinPerLangRunes, cfg.MinSummaryTotalRunes)
+		msg.UsePhoto = ok
+		if ok {
+			msg.Reason = fmt.Sprintf("POSTING_POLICY=%s: %s", policy, why)
+		} else {
+			msg.Reason = fmt.Sprintf("POSTING_POLICY=%s: photo rejected, %s", policy, why)
+		}
+	}
+
+	if msg.UsePhoto {
+		msg.Text = news.FormatCaptionForPhoto(n, cfg.PhotoCaptionMaxRunes, cfg.PhotoSentencesPerLang, cfg.PhotoMinPerLangRunes)
+	} else {
+		// text-only or hybrid fallback
+		msg.Text = news.FormatNewsWithImage(n, cfg.TextSentencesPerLangMin, cfg.TextSentencesPerLangMax)
+	}
+	return msg
+}
+
+// previewEntry is one dry-run decision: a message that would be sent or an item that was skipped
+type previewEntry struct {
+	Channel  string
+	Title    string
+	Link     string
+	Source   string
+	Skipped  string // non-empty when the item would not be sent
+	Photo    bool
+	Album    bool
+	PhotoURL string
+	ReplyTo  int64
+	Reason   string
+	Scoring  string
+	Payload  string
+	Runes    int
+}
+
+// preview collects dry-run decisions instead of calling Telegram
+type preview struct {
+	entries []previewEntry
+}
+
+func (p *preview) addMessage(msg outgoingMessage) {
+	e := previewEntry{
+		Channel: msg.Channel,
+		Title:   msg.News.Title,
+		Link:    msg.News.Link,
+		Source:  msg.News.SourceName,
+		Photo:   msg.UsePhoto,
+		Album:   msg.Album,
+		ReplyTo: msg.ReplyTo,
+		Reason:  msg.Reason,
+		Payload: msg.Text,
+		Runes:   utf8.RuneCountInString(msg.Text),
+	}
+	if msg.News.Scoring != nil {
+		e.Scoring = msg.News.Scoring.Summary()
+	}
+	if msg.UsePhoto {
+		e.PhotoURL = msg.News.ImageURL
+	}
+	p.entries = append(p.entries, e)
+}
+
+func (p *preview) addSkipped(n news.News, reason string) {
+	p.entries = append(p.entries, previewEntry{Title: n.Title, Link: n.Link, Source: n.SourceName, Skipped: reason})
+}
+
+// writeText prints every decision with the raw HTML payload
+func (p *preview) writeText(w io.Writer) {
+	sent := 0
+	for _, e := range p.entries {
+		if e.Skipped != "" {
+			fmt.Fprintf(w, "\n----- SKIPPED: %s\n      %s\n      reason: %s\n", e.Title, e.Link, e.Skipped)
+			continue
+		}
+		sent++
+		kind := "sendMessage"
+		if e.Photo {
+			kind = "sendPhoto " + e.PhotoURL
+		}
+		if e.Album {
+			kind = "sendMediaGroup item " + e.PhotoURL
+		}
+		if e.ReplyTo != 0 {
+			kind += fmt.Sprintf(" (reply to %d)", e.ReplyTo)
+		}
+		kind += " → " + e.Channel
+		fmt.Fprintf(w, "\n===== DRY RUN message %d: %s (%d runes)\n      reason: %s\n      score: %s\n%s\n", sent, kind, e.Runes, e.Reason, e.Scoring, e.Payload)
+	}
+	fmt.Fprintf(w, "\n===== DRY RUN: %d messages would be sent, %d items skipped\n", sent, len(p.entries)-sent)
+}
+
+var previewTemplate = template.Must(template.New("preview").Funcs(template.FuncMap{
+	"telegramHTML": func(s string) template.HTML {
+		// Payloads are Telegram HTML (already escaped by the formatters); only newlines need converting
+		return template.HTML(strings.ReplaceAll(s, "\n", "<br>"))
+	},
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>dknews dry run {{.Generated}}</title>
+<style>
+body { font-family: sans-serif; background: #e6ebee; max-width: 720px; margin: 2em auto; }
+.msg { background: #fff; border-radius: 10px; padding: 12px 16px; margin: 16px 0; box-shadow: 0 1px 2px #999; }
+.msg img { max-width: 100%; border-radius: 6px; }
+.meta { color: #666; font-size: 12px; margin-bottom: 8px; }
+.skipped { opacity: .6; }
+pre { white-space: pre-wrap; font-size: 11px; background: #f4f4f4; padding: 8px; }
+</style></head><body>
+<h1>Dry run — {{.Generated}}</h1>
+{{range .Entries}}{{if .Skipped}}<div class="msg skipped"><div class="meta">SKIPPED: {{.Skipped}}</div><a href="{{.Link}}">{{.Title}}</a></div>
+{{else}}<div class="msg">
+<div class="meta">{{if .Album}}sendMediaGroup item{{else if .Photo}}sendPhoto{{else}}sendMessage{{end}}{{if .ReplyTo}} (reply to {{.ReplyTo}}){{end}} → {{.Channel}} · {{.Runes}} runes · {{.Reason}}</div>
+{{if .Scoring}}<div class="meta">score: {{.Scoring}}</div>{{end}}
+{{if .Photo}}<img src="{{.PhotoURL}}" alt=""><br>{{end}}
+{{telegramHTML .Payload}}
+<details><summary>raw payload</summary><pre>{{.Payload}}</pre></details>
+</div>
+{{end}}{{end}}</body></html>
+`))
+
+// writeHTML renders a browser preview that looks roughly like the channel
+func (p *preview) writeHTML(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create preview file: %v", err)
+	}
+	defer f.Close()
+
+	data := struct {
+		Generated string
+		Entries   []previewEntry
+	}{time.Now().Format("2006-01-02 15:04"), p.entries}
+	if err := previewTemplate.Execute(f, data); err != nil {
+		return fmt.Errorf("failed to render preview: %v", err)
+	}
+	return nil
+}
+
+// readOnlyFeedStates lets a dry run use conditional GET state without saving the new ETags;
+// otherwise the next real run would get 304 for every feed the dry run saw and miss its items
+type readOnlyFeedStates struct{ rss.StateStore }
+
+func (readOnlyFeedStates) SaveFeedStates(map[string]rss.FeedState) error { return nil }
+
+// readOnlyLimiterStore respects the persisted daily quotas in a dry run without writing usage back
+type readOnlyLimiterStore struct{ ratelimit.Store }
+
+func (readOnlyLimiterStore) SaveRateLimitState(ratelimit.State) error { return nil }
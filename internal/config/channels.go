@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Channel is one Telegram destination with its own language layout and filters
+type Channel struct {
+	Name          string   `yaml:"name"`
+	ChatID        string   `yaml:"chat_id"`        // "${VAR}" is expanded from the environment
+	Layout        string   `yaml:"layout"`         // bilingual (default), uk-only or da-only
+	Categories    []string `yaml:"categories"`     // news categories to post (empty = all)
+	MinScore      int      `yaml:"min_score"`      // skip items scored lower
+	PostingPolicy string   `yaml:"posting_policy"` // empty = POSTING_POLICY
+	MaxItems      int      `yaml:"max_items"`      // per run; 0 = MAX_NEWS_LIMIT
+	LegacyHistory bool     `yaml:"legacy_history"` // owns the sent-news history of the single-channel setup
+}
+
+// ChannelsConfig is the YAML structure of CHANNELS_CONFIG_PATH
+type ChannelsConfig struct {
+	Channels []Channel `yaml:"channels"`
+}
+
+// Allows reports whether a news item of category with score may be posted to the channel
+func (ch Channel) Allows(category string, score int) bool {
+	if score < ch.MinScore {
+		return false
+	}
+	if len(ch.Categories) == 0 {
+		return true
+	}
+	for _, c := range ch.Categories {
+		if strings.EqualFold(c, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// Channels returns the posting destinations: the channels file if CHANNELS_CONFIG_PATH is set,
+// otherwise a single bilingual channel "main" posting to TELEGRAM_CHAT_ID with the legacy history.
+// Unset fields take the global settings; at most one channel may have legacy_history.
+func (c *Config) Channels() ([]Channel, error) {
+	if c.ChannelsConfigPath == "" {
+		return []Channel{{
+			Name:          "main",
+			ChatID:        c.TelegramChatID,
+			Layout:        "bilingual",
+			PostingPolicy: c.PostingPolicy,
+			MaxItems:      c.MaxNewsLimit,
+			LegacyHistory: true,
+		}}, nil
+	}
+
+	data, err := os.ReadFile(c.ChannelsConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read channels config: %v", err)
+	}
+	var file ChannelsConfig
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse channels config %s: %v", c.ChannelsConfigPath, err)
+	}
+	if len(file.Channels) == 0 {
+		return nil, fmt.Errorf("channels config %s has no channels", c.ChannelsConfigPath)
+	}
+
+	seen := make(map[string]bool)
+	legacy := ""
+	channels := make([]Channel, 0, len(file.Channels))
+	for i, ch := range file.Channels {
+		ch.Name = strings.TrimSpace(ch.Name)
+		if ch.Name == "" {
+			return nil, fmt.Errorf("channel #%d: name is empty", i+1)
+		}
+		if seen[ch.Name] {
+			return nil, fmt.Errorf("channel %s: duplicate name", ch.Name)
+		}
+		seen[ch.Name] = true
+
+		ch.ChatID = strings.TrimSpace(os.ExpandEnv(ch.ChatID))
+		if ch.ChatID == "" {
+			return nil, fmt.Errorf("channel %s: chat_id is empty", ch.Name)
+		}
+		ch.Layout = strings.ToLower(strings.TrimSpace(ch.Layout))
+		switch ch.Layout {
+		case "":
+			ch.Layout = "bilingual"
+		case "bilingual", "uk-only", "da-only":
+		default:
+			return nil, fmt.Errorf("channel %s: unknown layout %q (expected bilingual, uk-only or da-only)", ch.Name, ch.Layout)
+		}
+		if ch.PostingPolicy == "" {
+			ch.PostingPolicy = c.PostingPolicy
+		}
+		if ch.MaxItems <= 0 {
+			ch.MaxItems = c.MaxNewsLimit
+		}
+		if ch.LegacyHistory {
+			if legacy != "" {
+				return nil, fmt.Errorf("channel %s: legacy_history is already set on channel %s", ch.Name, legacy)
+			}
+			legacy = ch.Name
+		}
+		channels = append(channels, ch)
+	}
+	return channels, nil
+}
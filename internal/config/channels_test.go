@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChannels(t *testing.T) {
+	cfg := &Config{TelegramChatID: "@news", PostingPolicy: "hybrid", MaxNewsLimit: 8}
+	channels, err := cfg.Channels()
+	if err != nil || len(channels) != 1 || channels[0].ChatID != "@news" || channels[0].MaxItems != 8 || !channels[0].LegacyHistory {
+		t.Fatalf("default channel: %+v, %v", channels, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "channels.yaml")
+	if err := os.WriteFile(path, []byte(`channels:
+  - name: main
+    chat_id: ${TEST_CHANNEL_ID}
+    legacy_history: true
+  - name: family
+    chat_id: "@family"
+    layout: UK-only
+    categories: [family, education]
+    min_score: 20
+    posting_policy: text-only
+    max_items: 2
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_CHANNEL_ID", "-100123")
+	cfg.ChannelsConfigPath = path
+	channels, err = cfg.Channels()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	main, family := channels[0], channels[1]
+	if main.ChatID != "-100123" || main.Layout != "bilingual" || main.PostingPolicy != "hybrid" || main.MaxItems != 8 {
+		t.Errorf("main: %+v", main)
+	}
+	if !main.LegacyHistory || family.LegacyHistory {
+		t.Errorf("legacy_history: main %v, family %v", main.LegacyHistory, family.LegacyHistory)
+	}
+	if family.Layout != "uk-only" || family.PostingPolicy != "text-only" || family.MaxItems != 2 {
+		t.Errorf("family: %+v", family)
+	}
+	if !family.Allows("Education", 25) || family.Allows("education", 10) || family.Allows("denmark", 50) {
+		t.Errorf("family filters not applied")
+	}
+
+	if err := os.WriteFile(path, []byte("channels:\n  - name: a\n    chat_id: \"@a\"\n    layout: en-only\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Channels(); err == nil {
+		t.Errorf("expected error for unknown layout")
+	}
+
+	if err := os.WriteFile(path, []byte("channels:\n  - name: a\n    chat_id: \"@a\"\n    legacy_history: true\n  - name: b\n    chat_id: \"@b\"\n    legacy_history: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Channels(); err == nil {
+		t.Errorf("expected error for two legacy_history channels")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,10 +13,28 @@ type Config struct {
 	// Telegram settings
 	TelegramToken  string
 	TelegramChatID string
-	BotMode        string // "single" or "multiple"
+	BotMode        string // "single", "multiple" or "digest"
+	TelegramAPIURL string // Bot API server, e.g. a local telegram-bot-api (default https://api.telegram.org)
+
+	// Multi-channel posting; empty = post everything to TelegramChatID (see Channels)
+	ChannelsConfigPath string
+
+	// Telegram message options
+	TelegramSilent         bool // post without notification sound
+	TelegramProtectContent bool // forbid forwarding and saving posts
+
+	// Telegram flood limits (0 = documented default)
+	TelegramGlobalPerSecond int // messages per second across all chats (30)
+	TelegramChatPerMinute   int // messages per minute in one group or channel (20)
 
 	// Posting/formatting policy
 	PostingPolicy           string // hybrid | photo-only | text-only | two-messages (reserved)
+	AlbumMode               bool   // BOT_MODE=multiple: bundle photo posts into albums with a summary message
+	UploadPhotos            bool   // download, check and upload images instead of passing URLs to Telegram
+	ValidateImages          bool   // check size, aspect ratio and placeholders before choosing photo mode
+	ImageMinWidth           int    // smaller images are posted as text (400)
+	ImageMinHeight          int    // (200)
+	ImagePlaceholdersPath   string // perceptual hashes of known placeholder images
 	PhotoCaptionMaxRunes    int    // target/max caption budget for photo mode (~900)
 	PhotoMinPerLangRunes    int    // minimal budget per language in photo caption (≥120)
 	PhotoSentencesPerLang   int    // sentences per language in photo mode (1 or 2)
@@ -28,6 +47,9 @@ type Config struct {
 	GeminiAPIKey      string
 	MaxGeminiRequests int // maximum Gemini requests per run (0 = unlimited)
 
+	// AI providers chain, in fallback order; omit a name to disable it
+	AIProviders []string // e.g. gemini,groq,cohere,mistral,google
+
 	// AI Rate Limiting (NEW - saves tokens!)
 	MaxGroqRequests    int  // maximum Groq requests per run (0 = unlimited)
 	MaxCohereRequests  int  // maximum Cohere requests per run (0 = unlimited)
@@ -36,25 +58,63 @@ type Config struct {
 	EnableBatching     bool // enable batch processing for AI requests (saves ~40% tokens)
 	BatchSize          int  // number of news items to process in one AI request (2-3 recommended)
 
+	// Daily AI quotas (persisted between runs, 0 = unlimited)
+	DailyGeminiRequests  int
+	DailyGroqRequests    int
+	DailyCohereRequests  int
+	DailyMistralRequests int
+	DailyTotalAIRequests int
+	RateLimitStatePath   string // JSON file for limiter state when PostgreSQL is not used
+
 	// RSS settings
 	FeedsConfigPath string
 	MaxNewsLimit    int
 	NewsMaxAge      time.Duration
+	FeedConcurrency int    // parallel feed downloads
+	FeedStatePath   string // JSON file for ETag/Last-Modified state and feed health when PostgreSQL is not used
+
+	// Scoring
+	ScoringRulesPath string // keyword lists and category rules, reloaded when the file changes
+	ScoreReportPath  string // JSON report of every item's scoring decision per run (empty = off)
+
+	// Feed health
+	FeedDisableAfter    int           // consecutive failures before a feed is soft-disabled (0 = never)
+	FeedReprobeInterval time.Duration // how long a disabled feed waits for the next probe
+	FeedStaleDays       int           // warn when a feed has no new items for this many days (0 = off)
 
 	// Scraper settings
 	ScrapeConcurrency int // parallel fetches for full article extraction
 	ScrapeMaxArticles int // cap of articles to extract per run
 
+	// Daemon mode scheduling
+	Schedule         []string // cron expressions (5 fields), evaluated in ScheduleTimezone
+	ScheduleTimezone string   // IANA name, e.g. "Europe/Copenhagen"
+	QuietHours       string   // "HH:MM-HH:MM" window with no runs, e.g. "22:00-07:00"
+	RunOnStart       bool     // run the pipeline once immediately when the daemon starts
+
 	// App settings
 	Debug          bool
-	RequestTimeout time.Duration
+	RequestTimeout time.Duration // per-request timeout for feeds, scraping, AI and Telegram calls
+	RunTimeout     time.Duration // deadline for a whole pipeline run (0 = none)
 	RetryAttempts  int
 	RetryDelay     time.Duration
 
 	// Cache settings
-	CacheFilePath   string
-	CacheTTLHours   int
-	DuplicateWindow int // hours for duplicate detection
+	CacheFilePath          string
+	CacheTTLHours          int
+	DuplicateWindow        int // hours for duplicate detection
+	MaxFingerprintDistance int // SimHash bits two stories may differ by and still be duplicates
+
+	// Follow-up replies when a posted story gets a substantially updated article (opt-in: FOLLOW_UP_UPDATES=true)
+	FollowUpUpdates         bool
+	UpdateMinNoveltyPercent int // share of new words an article needs to count as an update
+	MaxUpdatesPerRun        int
+
+	// AI translation cache (file fallback when PostgreSQL is not used)
+	TranslationCachePath string
+
+	// Telegram file_ids of uploaded images (file fallback when PostgreSQL is not used)
+	PhotoCachePath string
 
 	// PostgreSQL settings
 	DatabaseURL string
@@ -63,7 +123,14 @@ type Config struct {
 
 }
 
+// Load reads configuration from environment and validates everything needed to post to Telegram
 func Load() (*Config, error) {
+	cfg := LoadEnv()
+	return cfg, cfg.Validate()
+}
+
+// LoadEnv reads configuration from environment without validation (for offline CLI commands)
+func LoadEnv() *Config {
 	cfg := &Config{
 		// Default values
 		FeedsConfigPath:         "configs/feeds.yaml",
@@ -72,6 +139,7 @@ func Load() (*Config, error) {
 		MaxCohereRequests:       5,    // Cohere has 100/month free limit
 		MaxMistralRequests:      5,    // Mistral free tier
 		MaxTotalAIRequests:      15,   // Total AI requests limit per run
+		DailyGeminiRequests:     250,  // Gemini 2.5 Flash free tier requests per day
 		EnableBatching:          true, // Enable batching by default (saves 40% tokens)
 		BatchSize:               2,    // Process 2 news items per AI request
 		MaxNewsLimit:            8,
@@ -88,14 +156,27 @@ func Load() (*Config, error) {
 		TextSentencesPerLangMax: 4,
 		MinSummaryTotalRunes:    180,
 		LanguagePriority:        "auto",
+		FeedConcurrency:         4,
+		FeedDisableAfter:        5,
+		FeedReprobeInterval:     6 * time.Hour,
+		FeedStaleDays:           3,
 		ScrapeConcurrency:       8,
 		ScrapeMaxArticles:       10,
 		DatabaseTTL:             48, // default TTL for database records
+		AIProviders:             []string{"gemini", "groq", "cohere", "mistral", "google"},
+		Schedule:                []string{"0 8 * * *"}, // Щодня о 8:00 UTC
+		ScheduleTimezone:        "UTC",
 	}
 
 	// Load from environment
 	cfg.TelegramToken = os.Getenv("TELEGRAM_TOKEN")
 	cfg.TelegramChatID = os.Getenv("TELEGRAM_CHAT_ID")
+	cfg.TelegramAPIURL = os.Getenv("TELEGRAM_API_URL")
+	cfg.ChannelsConfigPath = os.Getenv("CHANNELS_CONFIG_PATH")
+	cfg.TelegramSilent = os.Getenv("TELEGRAM_SILENT") == "true"
+	cfg.TelegramProtectContent = os.Getenv("TELEGRAM_PROTECT_CONTENT") == "true"
+	cfg.TelegramGlobalPerSecond = getEnvIntOrDefault("TELEGRAM_GLOBAL_PER_SECOND", 30)
+	cfg.TelegramChatPerMinute = getEnvIntOrDefault("TELEGRAM_CHAT_PER_MINUTE", 20)
 	cfg.GeminiAPIKey = os.Getenv("GEMINI_API_KEY")
 	cfg.DatabaseURL = os.Getenv("DATABASE_URL")
 
@@ -103,6 +184,16 @@ func Load() (*Config, error) {
 	cfg.CacheFilePath = getEnvOrDefault("CACHE_FILE_PATH", "sent_news.json")
 	cfg.CacheTTLHours = getEnvIntOrDefault("CACHE_TTL_HOURS", 48)
 	cfg.DuplicateWindow = getEnvIntOrDefault("DUPLICATE_WINDOW_HOURS", 24)
+	cfg.MaxFingerprintDistance = getEnvIntOrDefault("MAX_FINGERPRINT_DISTANCE", 12)
+	cfg.FollowUpUpdates = os.Getenv("FOLLOW_UP_UPDATES") == "true"
+	cfg.UpdateMinNoveltyPercent = getEnvIntOrDefault("UPDATE_MIN_NOVELTY_PERCENT", 40)
+	cfg.MaxUpdatesPerRun = getEnvIntOrDefault("MAX_UPDATES_PER_RUN", 2)
+	cfg.RateLimitStatePath = getEnvOrDefault("RATE_LIMIT_STATE_PATH", "ai_usage.json")
+	cfg.TranslationCachePath = getEnvOrDefault("TRANSLATION_CACHE_PATH", "translation_cache.json")
+	cfg.PhotoCachePath = getEnvOrDefault("PHOTO_CACHE_PATH", "photo_cache.json")
+	cfg.FeedStatePath = getEnvOrDefault("FEED_STATE_PATH", "feed_state.json")
+	cfg.ScoringRulesPath = getEnvOrDefault("SCORING_RULES_PATH", "configs/scoring_rules.yaml")
+	cfg.ScoreReportPath = os.Getenv("SCORE_REPORT_PATH")
 
 	if mode := os.Getenv("BOT_MODE"); mode != "" {
 		cfg.BotMode = mode
@@ -111,6 +202,12 @@ func Load() (*Config, error) {
 	if policy := os.Getenv("POSTING_POLICY"); policy != "" {
 		cfg.PostingPolicy = policy
 	}
+	cfg.AlbumMode = os.Getenv("ALBUM_MODE") == "true"
+	cfg.UploadPhotos = os.Getenv("UPLOAD_PHOTOS") != "false"
+	cfg.ValidateImages = os.Getenv("IMAGE_VALIDATION") != "false"
+	cfg.ImageMinWidth = getEnvIntOrDefault("IMAGE_MIN_WIDTH", 400)
+	cfg.ImageMinHeight = getEnvIntOrDefault("IMAGE_MIN_HEIGHT", 200)
+	cfg.ImagePlaceholdersPath = getEnvOrDefault("IMAGE_PLACEHOLDERS_PATH", "configs/placeholder_images.txt")
 	if v := os.Getenv("PHOTO_CAPTION_MAX_RUNES"); v != "" {
 		if val, err := strconv.Atoi(v); err == nil && val > 0 {
 			cfg.PhotoCaptionMaxRunes = val
@@ -145,6 +242,18 @@ func Load() (*Config, error) {
 		cfg.LanguagePriority = v
 	}
 
+	if v := os.Getenv("FEED_CONCURRENCY"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val > 0 {
+			cfg.FeedConcurrency = val
+		}
+	}
+	cfg.FeedDisableAfter = getEnvIntOrDefault("FEED_DISABLE_AFTER", cfg.FeedDisableAfter)
+	if v := os.Getenv("FEED_REPROBE_INTERVAL"); v != "" {
+		if val, err := time.ParseDuration(v); err == nil && val > 0 {
+			cfg.FeedReprobeInterval = val
+		}
+	}
+	cfg.FeedStaleDays = getEnvIntOrDefault("FEED_STALE_DAYS", cfg.FeedStaleDays)
 	if v := os.Getenv("SCRAPE_CONCURRENCY"); v != "" {
 		if val, err := strconv.Atoi(v); err == nil && val > 0 {
 			cfg.ScrapeConcurrency = val
@@ -156,6 +265,17 @@ func Load() (*Config, error) {
 		}
 	}
 
+	if v := os.Getenv("REQUEST_TIMEOUT"); v != "" {
+		if val, err := time.ParseDuration(v); err == nil && val > 0 {
+			cfg.RequestTimeout = val
+		}
+	}
+	if v := os.Getenv("RUN_TIMEOUT"); v != "" {
+		if val, err := time.ParseDuration(v); err == nil && val >= 0 {
+			cfg.RunTimeout = val
+		}
+	}
+
 	if debug := os.Getenv("DEBUG"); debug == "true" {
 		cfg.Debug = true
 	}
@@ -194,6 +314,14 @@ func Load() (*Config, error) {
 			cfg.MaxTotalAIRequests = val
 		}
 	}
+	cfg.DailyGeminiRequests = getEnvIntOrDefault("DAILY_GEMINI_REQUESTS", cfg.DailyGeminiRequests)
+	cfg.DailyGroqRequests = getEnvIntOrDefault("DAILY_GROQ_REQUESTS", cfg.DailyGroqRequests)
+	cfg.DailyCohereRequests = getEnvIntOrDefault("DAILY_COHERE_REQUESTS", cfg.DailyCohereRequests)
+	cfg.DailyMistralRequests = getEnvIntOrDefault("DAILY_MISTRAL_REQUESTS", cfg.DailyMistralRequests)
+	cfg.DailyTotalAIRequests = getEnvIntOrDefault("DAILY_TOTAL_AI_REQUESTS", cfg.DailyTotalAIRequests)
+	if v := os.Getenv("AI_PROVIDERS"); v != "" {
+		cfg.AIProviders = splitList(v)
+	}
 	if v := os.Getenv("ENABLE_BATCHING"); v != "" {
 		cfg.EnableBatching = v == "true"
 	}
@@ -203,12 +331,28 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Daemon mode: several expressions are separated by ";" (cron fields themselves use spaces and commas)
+	if v := os.Getenv("SCHEDULE"); v != "" {
+		var exprs []string
+		for _, e := range strings.Split(v, ";") {
+			if e = strings.TrimSpace(e); e != "" {
+				exprs = append(exprs, e)
+			}
+		}
+		cfg.Schedule = exprs
+	}
+	cfg.ScheduleTimezone = getEnvOrDefault("SCHEDULE_TZ", cfg.ScheduleTimezone)
+	cfg.QuietHours = os.Getenv("QUIET_HOURS")
+	if v := os.Getenv("RUN_ON_START"); v == "true" {
+		cfg.RunOnStart = true
+	}
+
 	// NEW: Check if PostgreSQL should be used
 	if usePg := os.Getenv("USE_POSTGRES"); usePg == "true" {
 		cfg.UsePostgres = true
 	}
 
-	return cfg, cfg.Validate()
+	return cfg
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -218,6 +362,17 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// splitList parses comma-separated env values, dropping empty entries
+func splitList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, strings.ToLower(part))
+		}
+	}
+	return out
+}
+
 func getEnvIntOrDefault(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -231,14 +386,19 @@ func (c *Config) Validate() error {
 	if c.TelegramToken == "" {
 		return fmt.Errorf("TELEGRAM_TOKEN is required")
 	}
-	if c.TelegramChatID == "" {
-		return fmt.Errorf("TELEGRAM_CHAT_ID is required")
+	if c.TelegramChatID == "" && c.ChannelsConfigPath == "" {
+		return fmt.Errorf("TELEGRAM_CHAT_ID or CHANNELS_CONFIG_PATH is required")
 	}
+	return c.ValidatePipeline()
+}
+
+// ValidatePipeline checks settings needed to fetch and summarize news (Telegram credentials not required)
+func (c *Config) ValidatePipeline() error {
 	if c.GeminiAPIKey == "" {
 		return fmt.Errorf("GEMINI_API_KEY is required")
 	}
-	if c.BotMode != "single" && c.BotMode != "multiple" {
-		return fmt.Errorf("BOT_MODE must be 'single' or 'multiple'")
+	if c.BotMode != "single" && c.BotMode != "multiple" && c.BotMode != "digest" {
+		return fmt.Errorf("BOT_MODE must be 'single', 'multiple' or 'digest'")
 	}
 	return nil
 }
@@ -0,0 +1,151 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/deusflow/News/internal/metrics"
+	"github.com/deusflow/News/internal/retry"
+	"github.com/deusflow/News/internal/translate"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// MaxBatchSize is the largest number of articles sent in one Gemini request
+const MaxBatchSize = 5
+
+// Client implements translate.BatchNewsProvider
+var _ translate.BatchNewsProvider = (*Client)(nil)
+
+// TranslateAndSummarizeBatch summarizes and translates up to MaxBatchSize articles in one request.
+// Cached articles are answered from cache; results are keyed by article ID and
+// articles that could not be parsed are simply absent from the map.
+// called is false when no request reached the API (everything cached, or no quota left).
+func (c *Client) TranslateAndSummarizeBatch(ctx context.Context, articles []translate.NewsArticle) (results map[string]*NewsTranslation, called bool, err error) {
+	if len(articles) > MaxBatchSize {
+		return nil, false, fmt.Errorf("batch too large: %d articles (max %d)", len(articles), MaxBatchSize)
+	}
+
+	results = make(map[string]*NewsTranslation, len(articles))
+	var pending []translate.NewsArticle
+	for _, a := range articles {
+		if cached, ok := c.lookupCached(a.Title, a.Content); ok {
+			results[a.ID] = cached
+			continue
+		}
+		pending = append(pending, a)
+	}
+	if len(pending) == 0 {
+		return results, false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*c.timeout)
+	defer cancel()
+
+	var response string
+	// Every attempt is a real API call and uses quota
+	err = retry.WithRetry(ctx, retry.RetryConfig{MaxAttempts: 3, Delay: 2 * time.Second, Backoff: true}, func() error {
+		if c.limiter != nil {
+			if err := c.limiter.UseGemini(); err != nil {
+				return retry.Permanent(err)
+			}
+		}
+		called = true
+		var err error
+		response, err = c.generateBatch(ctx, pending)
+		return err
+	})
+	if err != nil {
+		metrics.Global.SetError(fmt.Sprintf("Gemini batch API error: %v", err))
+		return results, called, err
+	}
+
+	parsed := parseBatchResponse(response)
+	for _, a := range pending {
+		tr, ok := parsed[a.ID]
+		if !ok {
+			log.Printf("⚠️ Gemini batch: no valid answer for article %s (%s)", a.ID, a.Title)
+			continue
+		}
+		c.storeCached(a.Title, a.Content, tr)
+		metrics.Global.IncrementSuccessfulTranslations()
+		results[a.ID] = tr
+	}
+	log.Printf("✅ Gemini batch: %d/%d articles parsed in one request", len(results), len(articles))
+	return results, true, nil
+}
+
+func (c *Client) generateBatch(ctx context.Context, articles []translate.NewsArticle) (string, error) {
+	model := c.client.GenerativeModel("gemini-2.5-flash")
+	model.SetTemperature(0.7)
+	model.SetTopK(40)
+	model.SetTopP(0.95)
+	maxTokens := int32(2048 * len(articles))
+	if maxTokens > 8192 {
+		maxTokens = 8192
+	}
+	model.SetMaxOutputTokens(maxTokens)
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = batchSchema
+
+	resp, err := model.GenerateContent(ctx, genai.Text(buildBatchPrompt(articles)))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content: %w", err)
+	}
+	return responseText(resp)
+}
+
+func buildBatchPrompt(articles []translate.NewsArticle) string {
+	var b strings.Builder
+	b.WriteString(`Аналізуй кожну з новин нижче та для КОЖНОЇ виконай завдання:
+
+Створи стислу версію новини (до 1000 символів) — поле "summary".
+Переклади новину на данську (природно, без дослівності) — поле "danish".
+Переклади новину на українську (природно) — поле "ukrainian".
+Переклади заголовок на українську — поле "ukrainian_title".
+Обери категорію новини зі списку: ` + strings.Join(newsCategories, ", ") + ` — поле "category".
+Оціни від 0 до 1, наскільки ти впевнений у якості перекладу — поле "confidence".
+
+ВИМОГИ:
+Не перекладати імена власні брендів/організацій.
+Уникати вводних слів типу «Новина про те, що…».
+Не змішувати новини між собою.
+Відповідай JSON-масивом: один об'єкт на кожну новину, поле "id" — номер новини з запиту.
+
+НОВИНИ:
+`)
+	for _, a := range articles {
+		b.WriteString(fmt.Sprintf("\n=== ARTICLE %s ===\nЗаголовок: %s\nЗміст: %s\n", a.ID, a.Title, prepareContent(a.Content, 2500)))
+	}
+	return b.String()
+}
+
+// parseBatchResponse decodes the JSON array and validates each article independently;
+// invalid or duplicate entries are dropped so those articles fall back to single requests
+func parseBatchResponse(response string) map[string]*NewsTranslation {
+	out := make(map[string]*NewsTranslation)
+	var items []newsJSON
+	if err := decodeStrict(response, &items); err != nil {
+		log.Printf("⚠️ Gemini batch: %v", err)
+		return out
+	}
+	for _, item := range items {
+		id := strings.TrimSpace(item.ID)
+		if id == "" {
+			continue
+		}
+		if _, dup := out[id]; dup {
+			continue
+		}
+		tr, err := item.validate()
+		if err != nil {
+			log.Printf("⚠️ Gemini batch: article %s rejected: %v", id, err)
+			continue
+		}
+		out[id] = tr
+	}
+	return out
+}
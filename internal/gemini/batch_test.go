@@ -0,0 +1,31 @@
+package gemini
+
+import "testing"
+
+func TestParseBatchResponse_MapsItemsByID(t *testing.T) {
+	response := `[
+  {"id": "0", "summary": "Уряд змінює правила", "danish": "Regeringen ændrer reglerne for flygtninge.",
+   "ukrainian": "Уряд Данії змінює правила для біженців.", "ukrainian_title": "Нові правила для біженців",
+   "category": "ukraine", "confidence": 0.9},
+  {"id": "2", "summary": "Щось", "danish": "Уряд Данії змінює правила.",
+   "ukrainian": "Regeringen ændrer reglerne.", "ukrainian_title": "Правила",
+   "category": "denmark", "confidence": 0.8},
+  {"id": "1", "summary": "Нова лінія метро", "danish": "København åbner en ny metrolinje.",
+   "ukrainian": "У Копенгагені відкривають нову лінію метро.", "ukrainian_title": "Нове метро в Копенгагені",
+   "category": "denmark", "confidence": 0.7}
+]`
+
+	got := parseBatchResponse(response)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 parsed articles, got %d: %v", len(got), got)
+	}
+	if got["0"] == nil || got["0"].UkrainianTitle != "Нові правила для біженців" || got["0"].Category != "ukraine" {
+		t.Errorf("article 0 parsed wrong: %+v", got["0"])
+	}
+	if got["1"] == nil || got["1"].Danish != "København åbner en ny metrolinje." {
+		t.Errorf("article 1 parsed wrong: %+v", got["1"])
+	}
+	if _, ok := got["2"]; ok {
+		t.Errorf("swapped languages must be rejected and left for single-request fallback")
+	}
+}
@@ -2,31 +2,37 @@ package gemini
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"regexp"
 	"strings"
 	"time"
 	"unicode/utf8"
 
 	"github.com/deusflow/News/internal/cache"
 	"github.com/deusflow/News/internal/metrics"
+	"github.com/deusflow/News/internal/ratelimit"
 	"github.com/deusflow/News/internal/retry"
+	"github.com/deusflow/News/internal/storage"
+	"github.com/deusflow/News/internal/translate"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
 )
 
 type Client struct {
-	client *genai.Client
-	cache  *cache.Cache
+	client  *genai.Client
+	cache   *cache.Cache
+	store   storage.TranslationStore
+	limiter *ratelimit.AIRateLimiter
+	timeout time.Duration
 }
 
-type NewsTranslation struct {
-	Summary   string
-	Danish    string
-	Ukrainian string
-}
+// NewsTranslation is kept as an alias so callers don't need to import translate
+type NewsTranslation = translate.NewsTranslation
+
+// Client implements translate.NewsProvider
+var _ translate.NewsProvider = (*Client)(nil)
 
 func NewClient(apiKey string) (*Client, error) {
 	ctx := context.Background()
@@ -36,8 +42,9 @@ func NewClient(apiKey string) (*Client, error) {
 	}
 
 	return &Client{
-		client: client,
-		cache:  cache.New(),
+		client:  client,
+		cache:   cache.New(),
+		timeout: 30 * time.Second,
 	}, nil
 }
 
@@ -47,15 +54,70 @@ func (c *Client) Close() {
 	}
 }
 
-func (c *Client) TranslateAndSummarizeNews(title, content string) (*NewsTranslation, error) {
-	// Check cache first
-	cacheKey := c.cache.GenerateKey(title, content)
-	if cached, found := c.cache.Get(cacheKey); found {
-		metrics.Global.IncrementSuccessfulTranslations()
-		return cached.(*NewsTranslation), nil
+// SetRateLimiter makes TranslateAndSummarizeNews reserve Gemini quota on every cache miss.
+// Translate and Summarize are limited by translate.Registry, which is how they are called.
+func (c *Client) SetRateLimiter(l *ratelimit.AIRateLimiter) {
+	c.limiter = l
+}
+
+// SetRequestTimeout bounds one article request including retries; batch requests get twice as long
+func (c *Client) SetRequestTimeout(d time.Duration) {
+	if d > 0 {
+		c.timeout = d
+	}
+}
+
+// SetTranslationStore adds a durable cache (PostgreSQL or file) consulted after the in-process cache
+func (c *Client) SetTranslationStore(store storage.TranslationStore) {
+	c.store = store
+}
+
+// Name returns provider name used in AI_PROVIDERS
+func (c *Client) Name() string { return "gemini" }
+
+// Capabilities reports that Gemini can translate, summarize and process whole articles
+func (c *Client) Capabilities() translate.Capability {
+	return translate.CapTranslate | translate.CapSummarize | translate.CapNews
+}
+
+// Translate translates plain text using the Gemini SDK
+func (c *Client) Translate(ctx context.Context, text, from, to string) (string, error) {
+	prompt := fmt.Sprintf("Translate the following text from %s to %s. Return ONLY the translation, no explanations:\n\n%s", translate.LanguageName(from), translate.LanguageName(to), text)
+	return c.generateText(ctx, prompt, 0.1, 1000)
+}
+
+// Summarize writes a short summary of text in lang using the Gemini SDK
+func (c *Client) Summarize(ctx context.Context, text, lang string) (string, error) {
+	prompt := fmt.Sprintf("Summarize the text in %s in 3-4 concise sentences. No preface, no lists, plain text.\n\nTEXT:\n%s", translate.LanguageName(lang), text)
+	return c.generateText(ctx, prompt, 0.2, 600)
+}
+
+// generateText runs a single plain-text prompt and returns trimmed model output
+func (c *Client) generateText(ctx context.Context, prompt string, temperature float32, maxTokens int32) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	model := c.client.GenerativeModel("gemini-2.5-flash")
+	model.SetTemperature(temperature)
+	model.SetMaxOutputTokens(maxTokens)
+
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content: %w", err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no response from Gemini")
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])), nil
+}
+
+func (c *Client) TranslateAndSummarizeNews(ctx context.Context, title, content string) (*NewsTranslation, error) {
+	// Check caches first
+	if cached, ok := c.lookupCached(title, content); ok {
+		return cached, nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
 	var result *NewsTranslation
@@ -68,49 +130,130 @@ func (c *Client) TranslateAndSummarizeNews(title, content string) (*NewsTranslat
 		Backoff:     true,
 	}
 
+	// Every attempt is a real API call and uses quota
 	err = retry.WithRetry(ctx, retryConfig, func() error {
+		if c.limiter != nil {
+			if err := c.limiter.UseGemini(); err != nil {
+				return retry.Permanent(err)
+			}
+		}
 		result, err = c.translateWithAPI(ctx, title, content)
 		return err
 	})
 
+	if errors.Is(err, ratelimit.ErrLimitExceeded) {
+		// Out of quota, not a Gemini failure
+		return nil, err
+	}
 	if err != nil {
 		metrics.Global.IncrementFailedTranslations()
 		metrics.Global.SetError(fmt.Sprintf("Gemini API error: %v", err))
 		return nil, err
 	}
 
-	// Cache successful translation for 24 hours
-	c.cache.Set(cacheKey, result, 24*time.Hour)
+	c.storeCached(title, content, result)
 	metrics.Global.IncrementSuccessfulTranslations()
 
 	return result, nil
 }
 
-func (c *Client) translateWithAPI(ctx context.Context, title, content string) (*NewsTranslation, error) {
-	// Используем самую новую стабильную версию Gemini 2.5 Flash (GA, released 17 Jun 2025)
-	model := c.client.GenerativeModel("gemini-2.5-flash")
+// lookupCached checks in-process cache, then the durable store (which survives between cron runs)
+func (c *Client) lookupCached(title, content string) (*NewsTranslation, bool) {
+	cacheKey := c.cache.GenerateKey(title, content)
+	if cached, found := c.cache.Get(cacheKey); found {
+		metrics.Global.IncrementSuccessfulTranslations()
+		if c.limiter != nil {
+			c.limiter.RecordCacheHit(estimateTokens(title + content))
+		}
+		return cached.(*NewsTranslation), true
+	}
 
-	// Configure model settings for better results
-	model.SetTemperature(0.7)
-	model.SetTopK(40)
-	model.SetTopP(0.95)
-	model.SetMaxOutputTokens(2048)
+	if c.store == nil {
+		return nil, false
+	}
+	item, err := c.store.GetTranslationCache(cacheKey)
+	if err != nil {
+		log.Printf("⚠️ Translation cache lookup failed: %v", err)
+		return nil, false
+	}
+	if item.ContentHash == "" || item.DanishTranslation == "" || item.UkrainianTranslation == "" {
+		return nil, false
+	}
 
-	// Sanitize & limit content size (avoid over-long prompts)
+	result := &NewsTranslation{
+		Summary:        item.Summary,
+		Danish:         item.DanishTranslation,
+		Ukrainian:      item.UkrainianTranslation,
+		UkrainianTitle: item.UkrainianTitle,
+		Category:       item.Category,
+		Confidence:     item.Confidence,
+	}
+	c.cache.Set(cacheKey, result, 24*time.Hour)
+	metrics.Global.IncrementSuccessfulTranslations()
+	if c.limiter != nil {
+		c.limiter.RecordCacheHit(estimateTokens(title + content))
+	}
+	log.Printf("💾 Cached translation used (provider=%s, uses=%d)", item.AIProvider, item.UseCount)
+	return result, true
+}
+
+// storeCached keeps a successful result in-process for 24 hours and in the durable store
+func (c *Client) storeCached(title, content string, result *NewsTranslation) {
+	cacheKey := c.cache.GenerateKey(title, content)
+	c.cache.Set(cacheKey, result, 24*time.Hour)
+	if c.store == nil {
+		return
+	}
+	err := c.store.SetTranslationCache(storage.TranslationCacheItem{
+		ContentHash:          cacheKey,
+		Title:                title,
+		Content:              content,
+		Summary:              result.Summary,
+		DanishTranslation:    result.Danish,
+		UkrainianTranslation: result.Ukrainian,
+		UkrainianTitle:       result.UkrainianTitle,
+		Category:             result.Category,
+		Confidence:           result.Confidence,
+		AIProvider:           c.Name(),
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to store translation in cache: %v", err)
+	}
+}
+
+// prepareContent sanitizes article text and limits its size (avoid over-long prompts)
+func prepareContent(content string, maxChars int) string {
 	content = strings.ReplaceAll(content, "\r", "")
 	content = strings.TrimSpace(content)
 	// Collapse excessive whitespace
 	content = strings.Join(strings.Fields(content), " ")
-	maxChars := 6000
 	if utf8.RuneCountInString(content) > maxChars {
 		// cut on rune boundary then try to end at sentence
 		runes := []rune(content)
 		trimmed := string(runes[:maxChars])
-		if idx := strings.LastIndex(trimmed, ". "); idx > 1200 { // keep some meaningful size
+		if idx := strings.LastIndex(trimmed, ". "); idx > maxChars/5 { // keep some meaningful size
 			trimmed = trimmed[:idx+1]
 		}
 		content = trimmed + "\n[TRUNCATED]"
 	}
+	return content
+}
+
+func (c *Client) translateWithAPI(ctx context.Context, title, content string) (*NewsTranslation, error) {
+	// Используем самую новую стабильную версию Gemini 2.5 Flash (GA, released 17 Jun 2025)
+	model := c.client.GenerativeModel("gemini-2.5-flash")
+
+	// Configure model settings for better results
+	model.SetTemperature(0.7)
+	model.SetTopK(40)
+	model.SetTopP(0.95)
+	model.SetMaxOutputTokens(2048)
+	// Structured output: the model must answer with a single JSON object matching newsSchema
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = newsSchema
+
+	// Sanitize & limit content size (avoid over-long prompts)
+	content = prepareContent(content, 6000)
 
 	prompt := fmt.Sprintf(`
 Аналізуй цю новину та виконай наступні завдання:
@@ -121,233 +264,61 @@ func (c *Client) translateWithAPI(ctx context.Context, title, content string) (*
 
 ЗАВДАННЯ:
 
-Створи стислу версію новини (до 1500 символів).
-
-Переклади цю новину на данську (природно, без дослівності).
-
-Переклади цю новину на українську (природно).
-
-ВИМОГИ:
-
-Не перекладати імена власні брендів/організацій.
-
-Уникати вводних слів типу «Новина про те, що…».
-
-Формат відповіді суворо за шаблоном нижче.
-
-СУТЬ: <коротка суть як тема>
+Створи стислу версію новини (до 1500 символів) — поле "summary".
 
-UKRAINIAN: <переклад на українську>
+Переклади цю новину на данську (природно, без дослівності) — поле "danish".
 
-DANSK: <переклад на данську>
+Переклади цю новину на українську (природно) — поле "ukrainian".
 
+Переклади заголовок на українську — поле "ukrainian_title".
 
-Приклад:
+Обери категорію новини зі списку: %s — поле "category".
 
-СУТЬ: Новий продукт Y від компанії X, який революціонізує галузь Z.
+Оціни від 0 до 1, наскільки ти впевнений у якості перекладу — поле "confidence".
 
-UKRAINIAN: Компанія X презентувала свій новий продукт Y, який уже називають справжнім технологічним проривом. За словами керівництва компанії, цей продукт здатний не лише вдосконалити існуючі процеси у галузі Z, але й повністю змінити уявлення про те, як має працювати ця сфера в майбутньому.
-
-Особливістю продукту Y є поєднання новітніх наукових досліджень, штучного інтелекту та сучасних дизайнерських підходів. Генеральний директор компанії X підкреслив, що ця розробка стала результатом багаторічної праці та тісної співпраці з провідними експертами галузі.
-
-Продукт Y обіцяє зменшити витрати, підвищити ефективність та створити нові робочі місця. Уже зараз експерти прогнозують, що протягом кількох років використання цього продукту стане стандартом у галузі Z.
-
-Компанія X планує розпочати масове виробництво Y найближчими місяцями, а перші клієнти вже отримали можливість протестувати новинку. Реакція ринку виявилася надзвичайною: багато компаній заявили про готовність якнайшвидше інтегрувати Y у свої процеси.
-
-Аналітики впевнені, що ми стоїмо на порозі нової ери розвитку галузі Z, і саме продукт Y може стати ключовим елементом цього процесу.
-
-DANSK: Firmaet X har lanceret sit nyeste produkt, Y, som allerede bliver omtalt som et teknologisk gennembrud. Ifølge ledelsen i virksomheden vil produktet ikke blot forbedre de eksisterende processer i branche Z, men også ændre hele opfattelsen af, hvordan sektoren skal fungere i fremtiden.
+ВИМОГИ:
 
-Produkt Y kombinerer banebrydende forskning, kunstig intelligens og innovative designmetoder. Administrerende direktør i firma X fortæller, at løsningen er resultatet af mange års udvikling og tæt samarbejde med de førende eksperter på området.
+Не перекладати імена власні брендів/організацій.
 
-Med Y kan virksomheder reducere omkostninger, øge effektiviteten og skabe nye arbejdspladser. Eksperter forudser allerede nu, at produktet inden for få år bliver en standard i branche Z.
+Уникати вводних слів типу «Новина про те, що…».
 
-Firma X planlægger at starte masseproduktion af Y i de kommende måneder, og de første kunder har allerede fået mulighed for at teste produktet. Markedets reaktion har været imponerende: mange virksomheder viser stor interesse for at implementere Y så hurtigt som muligt.
+Поле "danish" — тільки данською, поля "ukrainian" та "ukrainian_title" — тільки українською.
 
-Analytikere peger på, at vi står foran en ny æra i udviklingen af branche Z, hvor produktet Y kan blive en afgørende drivkraft.
-.
-`, title, content)
+Відповідай лише JSON-об'єктом без пояснень.
+`, title, content, strings.Join(newsCategories, ", "))
 
 	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate content: %w", err)
 	}
 
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("no response from Gemini")
+	response, err := responseText(resp)
+	if err != nil {
+		return nil, err
 	}
-
-	response := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
-	return parseGeminiResponse(response)
+	return parseNewsJSON(response)
 }
 
-func parseGeminiResponse(response string) (*NewsTranslation, error) {
-	lines := strings.Split(response, "\n")
-
-	var summaryBuilder, danishBuilder, ukrainianBuilder strings.Builder
-
-	// Patterns for labels (case-insensitive, optional colon, allow Cyrillic variants)
-	labelPatterns := []struct {
-		name  string
-		regex *regexp.Regexp
-	}{
-		{"summary", regexp.MustCompile(`(?i)^(СУТЬ|Суть)\s*: ?`)},
-		{"danish", regexp.MustCompile(`(?i)^(DANSK|ДАНСЬКА)\s*: ?`)},
-		{"ukrainian", regexp.MustCompile(`(?i)^(UKRAINIAN|УКРАЇНСЬКА|УКРАИНСКИЙ|УКРАЇНСЬКА МОВА)\s*: ?`)},
+// responseText joins the text parts of the first candidate and rejects answers cut off by the token limit
+func responseText(resp *genai.GenerateContentResponse) (string, error) {
+	if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no response from Gemini")
 	}
-
-	current := ""
-
-	appendText := func(section string, text string) {
-		if text == "" {
-			return
-		}
-		switch section {
-		case "summary":
-			if summaryBuilder.Len() > 0 {
-				summaryBuilder.WriteString(" ")
-			}
-			summaryBuilder.WriteString(text)
-		case "danish":
-			if danishBuilder.Len() > 0 {
-				danishBuilder.WriteString(" ")
-			}
-			danishBuilder.WriteString(text)
-		case "ukrainian":
-			if ukrainianBuilder.Len() > 0 {
-				ukrainianBuilder.WriteString(" ")
-			}
-			ukrainianBuilder.WriteString(text)
-		}
-	}
-
-	for _, raw := range lines {
-		line := strings.TrimSpace(raw)
-		if line == "" {
-			continue
-		}
-
-		matchedLabel := false
-		for _, lp := range labelPatterns {
-			if lp.regex.MatchString(line) {
-				// New section
-				content := lp.regex.ReplaceAllString(line, "")
-				current = lp.name
-				appendText(current, strings.TrimSpace(content))
-				matchedLabel = true
-				break
-			}
-		}
-		if matchedLabel {
-			continue
-		}
-
-		// Continuation line for current section
-		if current != "" {
-			appendText(current, line)
-		}
+	cand := resp.Candidates[0]
+	if cand.FinishReason == genai.FinishReasonMaxTokens {
+		return "", fmt.Errorf("gemini response truncated by max output tokens")
 	}
 
-	summary := strings.TrimSpace(summaryBuilder.String())
-	danish := strings.TrimSpace(danishBuilder.String())
-	ukrainian := strings.TrimSpace(ukrainianBuilder.String())
-
-	// Fallback: older label names (legacy) if nothing parsed
-	if summary == "" || danish == "" || ukrainian == "" {
-		legacySummaryPrefix := "СУТЬ:"
-		legacyDanishPrefix := "ДАТСКИЙ:"
-		legacyUkrainianPrefix := "УКРАИНСКИЙ:"
-		if summary == "" || danish == "" || ukrainian == "" {
-			for _, line := range lines {
-				l := strings.TrimSpace(line)
-				if summary == "" && strings.HasPrefix(l, legacySummaryPrefix) {
-					summary = strings.TrimSpace(strings.TrimPrefix(l, legacySummaryPrefix))
-				}
-				if danish == "" && strings.HasPrefix(l, legacyDanishPrefix) {
-					danish = strings.TrimSpace(strings.TrimPrefix(l, legacyDanishPrefix))
-				}
-				if ukrainian == "" && strings.HasPrefix(l, legacyUkrainianPrefix) {
-					ukrainian = strings.TrimSpace(strings.TrimPrefix(l, legacyUkrainianPrefix))
-				}
-			}
+	var b strings.Builder
+	for _, part := range cand.Content.Parts {
+		if t, ok := part.(genai.Text); ok {
+			b.WriteString(string(t))
 		}
 	}
-
-	// Additional fallback: naive split into three large blocks if still missing
-	if summary == "" || danish == "" || ukrainian == "" {
-		log.Printf("Warning: fallback parsing triggered. Raw response: %s", response)
-		chunks := []string{}
-		acc := strings.Builder{}
-		for _, raw := range lines {
-			l := strings.TrimSpace(raw)
-			if l == "" {
-				continue
-			}
-			acc.WriteString(l)
-			acc.WriteString(" ")
-			if len(acc.String()) > 1200 { // heuristic split
-				chunks = append(chunks, strings.TrimSpace(acc.String()))
-				acc.Reset()
-			}
-		}
-		if acc.Len() > 0 {
-			chunks = append(chunks, strings.TrimSpace(acc.String()))
-		}
-		for _, c := range chunks {
-			if summary == "" {
-				summary = c
-				continue
-			}
-			if danish == "" {
-				danish = c
-				continue
-			}
-			if ukrainian == "" {
-				ukrainian = c
-				continue
-			}
-		}
-	}
-
-	// Detect accidental swap (Gemini sometimes flips labels). Simple heuristic by alphabet presence.
-	if looksUkrainian(danish) && looksDanish(ukrainian) {
-		log.Printf("Info: swapping Danish/Ukrainian blocks (detected inversion)")
-		danish, ukrainian = ukrainian, danish
-	}
-	// Another swap case: Danish missing Danish letters but Ukrainian text contains none Ukrainian letters -> skip
-
-	if summary == "" || danish == "" || ukrainian == "" {
-		return nil, fmt.Errorf("could not parse Gemini response: missing required fields (summary=%t danish=%t ukrainian=%t)", summary != "", danish != "", ukrainian != "")
-	}
-
-	return &NewsTranslation{Summary: summary, Danish: danish, Ukrainian: ukrainian}, nil
+	return b.String(), nil
 }
 
-func looksUkrainian(s string) bool {
-	// Count distinctive Ukrainian letters
-	ukChars := "іїєґЙйЖжШшЩщЮюЯяІіЄєҐґ"
-	count := 0
-	for _, r := range s {
-		if strings.ContainsRune(ukChars, r) {
-			count++
-		}
-		if count > 3 {
-			return true
-		}
-	}
-	return false
-}
-
-func looksDanish(s string) bool {
-	daChars := "æøåÆØÅ"
-	count := 0
-	for _, r := range s {
-		if strings.ContainsRune(daChars, r) {
-			count++
-		}
-		if count > 2 {
-			return true
-		}
-	}
-	return false
+// estimateTokens roughly estimates prompt + answer tokens for one article (~4 runes per token)
+func estimateTokens(text string) int {
+	return utf8.RuneCountInString(text)/4 + 1500
 }
@@ -0,0 +1,171 @@
+package gemini
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// newsCategories mirrors the categories assigned by news scoring
+var newsCategories = []string{
+	"ukraine", "denmark", "europe", "conflict", "economy", "health", "tech", "family",
+	"youth", "culture", "sports", "environment", "education", "general",
+}
+
+// newsFields describes one processed article; shared by single and batch schemas
+var newsFields = map[string]*genai.Schema{
+	"summary":         {Type: genai.TypeString, Description: "Short summary of the article, up to 1500 characters"},
+	"danish":          {Type: genai.TypeString, Description: "Natural Danish translation of the article"},
+	"ukrainian":       {Type: genai.TypeString, Description: "Natural Ukrainian translation of the article"},
+	"ukrainian_title": {Type: genai.TypeString, Description: "Ukrainian translation of the title"},
+	"category":        {Type: genai.TypeString, Format: "enum", Enum: newsCategories},
+	"confidence":      {Type: genai.TypeNumber, Description: "Translation quality confidence from 0 to 1"},
+}
+
+var newsRequired = []string{"summary", "danish", "ukrainian", "ukrainian_title", "category", "confidence"}
+
+// newsSchema is the response schema for TranslateAndSummarizeNews
+var newsSchema = &genai.Schema{
+	Type:       genai.TypeObject,
+	Properties: newsFields,
+	Required:   newsRequired,
+}
+
+// batchSchema is the response schema for TranslateAndSummarizeBatch: one object per article, tagged with its id
+var batchSchema = &genai.Schema{
+	Type: genai.TypeArray,
+	Items: &genai.Schema{
+		Type:       genai.TypeObject,
+		Properties: withField(newsFields, "id", &genai.Schema{Type: genai.TypeString, Description: "Article id from the request"}),
+		Required:   append([]string{"id"}, newsRequired...),
+	},
+}
+
+func withField(fields map[string]*genai.Schema, name string, s *genai.Schema) map[string]*genai.Schema {
+	out := make(map[string]*genai.Schema, len(fields)+1)
+	for k, v := range fields {
+		out[k] = v
+	}
+	out[name] = s
+	return out
+}
+
+// newsJSON is the wire format of one article in Gemini's answer
+type newsJSON struct {
+	ID             string   `json:"id,omitempty"`
+	Summary        string   `json:"summary"`
+	Danish         string   `json:"danish"`
+	Ukrainian      string   `json:"ukrainian"`
+	UkrainianTitle string   `json:"ukrainian_title"`
+	Category       string   `json:"category"`
+	Confidence     *float64 `json:"confidence"`
+}
+
+// parseNewsJSON decodes and validates a single-article answer
+func parseNewsJSON(response string) (*NewsTranslation, error) {
+	var item newsJSON
+	if err := decodeStrict(response, &item); err != nil {
+		return nil, err
+	}
+	return item.validate()
+}
+
+// decodeStrict decodes JSON rejecting unknown fields and trailing data
+func decodeStrict(response string, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader([]byte(stripCodeFence(response))))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid Gemini JSON: %v", err)
+	}
+	if dec.More() {
+		return fmt.Errorf("invalid Gemini JSON: unexpected data after value")
+	}
+	return nil
+}
+
+// stripCodeFence removes a ```json ... ``` wrapper the model sometimes adds despite the JSON mime type
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimPrefix(s, "json")
+	s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	return strings.TrimSpace(s)
+}
+
+// validate checks that every field is present and written in the expected language
+func (n newsJSON) validate() (*NewsTranslation, error) {
+	tr := &NewsTranslation{
+		Summary:        strings.TrimSpace(n.Summary),
+		Danish:         strings.TrimSpace(n.Danish),
+		Ukrainian:      strings.TrimSpace(n.Ukrainian),
+		UkrainianTitle: strings.TrimSpace(n.UkrainianTitle),
+		Category:       strings.ToLower(strings.TrimSpace(n.Category)),
+	}
+
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"summary", tr.Summary}, {"danish", tr.Danish}, {"ukrainian", tr.Ukrainian},
+		{"ukrainian_title", tr.UkrainianTitle}, {"category", tr.Category},
+	} {
+		if f.value == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if n.Confidence == nil {
+		missing = append(missing, "confidence")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("gemini response missing required fields: %s", strings.Join(missing, ", "))
+	}
+
+	if *n.Confidence < 0 || *n.Confidence > 1 {
+		return nil, fmt.Errorf("gemini confidence out of range: %v", *n.Confidence)
+	}
+	tr.Confidence = *n.Confidence
+
+	if !isKnownCategory(tr.Category) {
+		return nil, fmt.Errorf("gemini returned unknown category %q", tr.Category)
+	}
+	if cyrillicShare(tr.Danish) > 0.2 {
+		return nil, fmt.Errorf("gemini danish field is not Danish")
+	}
+	// Titles are short and often dominated by Latin brand names, so they get a lower bar
+	if cyrillicShare(tr.Ukrainian) < 0.5 || cyrillicShare(tr.UkrainianTitle) < 0.3 {
+		return nil, fmt.Errorf("gemini ukrainian field is not Ukrainian")
+	}
+	return tr, nil
+}
+
+func isKnownCategory(c string) bool {
+	for _, known := range newsCategories {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+// cyrillicShare returns the fraction of letters in s that are Cyrillic
+func cyrillicShare(s string) float64 {
+	letters, cyrillic := 0, 0
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Cyrillic, r) {
+			cyrillic++
+		}
+	}
+	if letters == 0 {
+		return 0
+	}
+	return float64(cyrillic) / float64(letters)
+}
@@ -0,0 +1,36 @@
+package gemini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNewsJSON_Validation(t *testing.T) {
+	valid := `{"summary": "Нова лінія метро", "danish": "København åbner en ny metrolinje.",
+		"ukrainian": "У Копенгагені відкривають нову лінію метро.", "ukrainian_title": "Нове метро",
+		"category": "Denmark", "confidence": 0.8}`
+
+	tr, err := parseNewsJSON("```json\n" + valid + "\n```")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.Category != "denmark" || tr.Confidence != 0.8 || tr.UkrainianTitle != "Нове метро" {
+		t.Errorf("unexpected result: %+v", tr)
+	}
+
+	cases := map[string]string{
+		"truncated":        valid[:len(valid)/2],
+		"missing field":    strings.Replace(valid, `"ukrainian_title": "Нове метро",`, "", 1),
+		"null confidence":  strings.Replace(valid, "0.8", "null", 1),
+		"bad confidence":   strings.Replace(valid, "0.8", "7", 1),
+		"unknown category": strings.Replace(valid, "Denmark", "gossip", 1),
+		"unknown field":    strings.Replace(valid, `"category"`, `"extra": 1, "category"`, 1),
+		"danish in ukrainian": strings.Replace(valid, "У Копенгагені відкривають нову лінію метро.",
+			"København åbner en ny metrolinje.", 1),
+	}
+	for name, response := range cases {
+		if _, err := parseNewsJSON(response); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
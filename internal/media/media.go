@@ -0,0 +1,228 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	// decoders for image.Decode
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Bot API limits for photos (https://core.telegram.org/bots/api#sendphoto)
+const (
+	MaxPhotoBytes = 10 << 20 // uploaded photo size
+	MaxPhotoRatio = 20       // longer side / shorter side
+)
+
+// MaxDownloadBytes is the largest source image we download; bigger images are too heavy to transcode
+const MaxDownloadBytes = 25 << 20
+
+// maxPixels guards against decompression bombs: a small file that decodes to a huge bitmap
+const maxPixels = 50_000_000
+
+// MaxSide is the longest side of an uploaded photo; Telegram shows at most 2560px, anything bigger is wasted upload.
+// It also keeps width + height under the Bot API limit of 10000.
+const MaxSide = 2560
+
+// jpegQuality is used when an image is re-encoded
+const jpegQuality = 85
+
+// requestTimeout bounds a single image download
+var requestTimeout = 15 * time.Second
+
+// SetRequestTimeout changes the per-image timeout (d <= 0 keeps the current value)
+func SetRequestTimeout(d time.Duration) {
+	if d > 0 {
+		requestTimeout = d
+	}
+}
+
+// Image is a downloaded photo ready for upload
+type Image struct {
+	URL          string
+	Data         []byte // JPEG as uploaded, or the original bytes when they were fine as is
+	Format       string // format of Data: "jpeg" or "png"
+	SourceFormat string // format served by the site: jpeg, png, gif or webp
+	Width        int
+	Height       int
+	Transcoded   bool // re-encoded or resized
+}
+
+// Fetch downloads url and prepares it for Telegram (see Prepare).
+// The request is aborted when ctx is cancelled.
+func Fetch(ctx context.Context, url string) (*Image, error) {
+	client := &http.Client{Timeout: requestTimeout}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error make request: %v", err)
+	}
+	// Some outlets refuse requests without a browser-like User-Agent
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; DanishNewsBot/1.0)")
+	req.Header.Set("Accept", "image/jpeg,image/png,image/webp,image/gif;q=0.8,*/*;q=0.5")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error loading image: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+	if resp.ContentLength > MaxDownloadBytes {
+		return nil, fmt.Errorf("image too large: %d bytes", resp.ContentLength)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !acceptedContentType(contentType) {
+		return nil, fmt.Errorf("not an image: %s", contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxDownloadBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading image: %v", err)
+	}
+	if len(data) > MaxDownloadBytes {
+		return nil, fmt.Errorf("image too large: more than %d bytes", MaxDownloadBytes)
+	}
+
+	img, err := Prepare(data)
+	if err != nil {
+		return nil, err
+	}
+	img.URL = url
+	return img, nil
+}
+
+// SniffFormat names the format of an image that image.DecodeConfig cannot read
+// ("avif", "heic", "bmp"...), or returns "" for decodable formats, SVG and non-images
+func SniffFormat(data []byte) string {
+	// ISO-BMFF: ....ftyp<brand>
+	if len(data) >= 12 && string(data[4:8]) == "ftyp" {
+		switch string(data[8:12]) {
+		case "avif", "avis":
+			return "avif"
+		case "heic", "heix", "mif1":
+			return "heic"
+		}
+		return ""
+	}
+	ct := http.DetectContentType(data)
+	if !strings.HasPrefix(ct, "image/") {
+		return ""
+	}
+	switch f := strings.TrimPrefix(ct, "image/"); f {
+	case "jpeg", "png", "gif", "webp", "x-icon":
+		return ""
+	default:
+		return f
+	}
+}
+
+// acceptedContentType lets through images and the generic types some CDNs send for them
+func acceptedContentType(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return ct == "" || strings.HasPrefix(ct, "image/") || ct == "application/octet-stream" || ct == "binary/octet-stream"
+}
+
+// ErrUnsupportedFormat means the data is an image we cannot decode (AVIF, HEIC, BMP...).
+// It is not transcoded; callers pass the image URL to Telegram instead of uploading it.
+var ErrUnsupportedFormat = errors.New("image format not supported for upload")
+
+// Prepare checks an image and converts it to something sendPhoto accepts:
+// JPEG and PNG within the limits are kept as is; GIF, WebP, oversized or extreme images
+// are resized to MaxSide and re-encoded as JPEG. There is no AVIF decoder: AVIF and other
+// images we cannot decode return ErrUnsupportedFormat, so the caller falls back to the URL.
+// SVG and non-images are rejected.
+func Prepare(data []byte) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if f := SniffFormat(data); f != "" {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, f)
+		}
+		return nil, fmt.Errorf("unsupported image (%s): %v", http.DetectContentType(data), err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("image has no size")
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("image too large: %dx%d", cfg.Width, cfg.Height)
+	}
+	if ratio(cfg.Width, cfg.Height) > MaxPhotoRatio {
+		return nil, fmt.Errorf("image ratio too extreme: %dx%d", cfg.Width, cfg.Height)
+	}
+
+	img := &Image{SourceFormat: format, Format: format, Data: data, Width: cfg.Width, Height: cfg.Height}
+	if (format == "jpeg" || format == "png") && len(data) <= MaxPhotoBytes &&
+		cfg.Width <= MaxSide && cfg.Height <= MaxSide {
+		return img, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s image: %v", format, err)
+	}
+	scaled := fit(src, MaxSide)
+	out, err := encodeJPEG(scaled)
+	if err != nil {
+		return nil, err
+	}
+	b := scaled.Bounds()
+	img.Data, img.Format, img.Width, img.Height, img.Transcoded = out, "jpeg", b.Dx(), b.Dy(), true
+	if len(img.Data) > MaxPhotoBytes {
+		return nil, fmt.Errorf("image still too large after transcoding: %d bytes", len(img.Data))
+	}
+	return img, nil
+}
+
+func ratio(w, h int) float64 {
+	if w < h {
+		w, h = h, w
+	}
+	return float64(w) / float64(h)
+}
+
+// fit scales src down so that neither side exceeds maxSide
+func fit(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+	if w >= h {
+		h = max(1, h*maxSide/w)
+		w = maxSide
+	} else {
+		w = max(1, w*maxSide/h)
+		h = maxSide
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// encodeJPEG flattens transparency onto white (JPEG has no alpha) and encodes src
+func encodeJPEG(src image.Image) ([]byte, error) {
+	b := src.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, b.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("error encoding JPEG: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,110 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 128})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPrepare_KeepsSmallPNG(t *testing.T) {
+	data := encodePNG(t, 800, 600)
+	img, err := Prepare(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if img.Transcoded || img.Format != "png" || !bytes.Equal(img.Data, data) {
+		t.Errorf("small PNG should be kept as is: %+v", img.Format)
+	}
+}
+
+func TestPrepare_ResizesLargeImage(t *testing.T) {
+	img, err := Prepare(encodePNG(t, 4000, 1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !img.Transcoded || img.Format != "jpeg" || img.Width != MaxSide || img.Height != 640 {
+		t.Errorf("unexpected result: %s %dx%d transcoded=%v", img.Format, img.Width, img.Height, img.Transcoded)
+	}
+	if _, format, err := image.DecodeConfig(bytes.NewReader(img.Data)); err != nil || format != "jpeg" {
+		t.Errorf("output is not a JPEG: %v %s", err, format)
+	}
+}
+
+func TestPrepare_TranscodesGIF(t *testing.T) {
+	var buf bytes.Buffer
+	pal := image.NewPaletted(image.Rect(0, 0, 300, 200), []color.Color{color.Black, color.White})
+	if err := gif.Encode(&buf, pal, nil); err != nil {
+		t.Fatal(err)
+	}
+	img, err := Prepare(buf.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if img.SourceFormat != "gif" || img.Format != "jpeg" || img.Width != 300 || img.Height != 200 {
+		t.Errorf("unexpected result: %s→%s %dx%d", img.SourceFormat, img.Format, img.Width, img.Height)
+	}
+}
+
+func TestPrepare_Rejects(t *testing.T) {
+	if _, err := Prepare([]byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>")); err == nil {
+		t.Errorf("expected error for SVG")
+	}
+	if _, err := Prepare(encodePNG(t, 2100, 100)); err == nil {
+		t.Errorf("expected error for a 21:1 banner")
+	}
+}
+
+// avifHeader is the start of an AVIF file: an ftyp box with the avif brand
+var avifHeader = []byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00avifmif1miaf")
+
+func TestPrepare_UnsupportedFormat(t *testing.T) {
+	if _, err := Prepare(avifHeader); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat for AVIF, got %v", err)
+	}
+	if _, err := Prepare([]byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>")); errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("SVG must be rejected, not passed by URL")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	data := encodePNG(t, 400, 300)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/page" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	img, err := Fetch(context.Background(), srv.URL+"/a.png")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if img.URL != srv.URL+"/a.png" || img.Width != 400 {
+		t.Errorf("unexpected image: %s %dx%d", img.URL, img.Width, img.Height)
+	}
+	if _, err := Fetch(context.Background(), srv.URL+"/page"); err == nil {
+		t.Errorf("expected error for an HTML page")
+	}
+}
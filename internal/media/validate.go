@@ -0,0 +1,278 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"math/bits"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Rules decide whether an article image is good enough to post as a photo
+type Rules struct {
+	MinWidth        int      // 400 when 0
+	MinHeight       int      // 200 when 0
+	MinRatio        float64  // width / height; 0.5 when 0 (tall strips)
+	MaxRatio        float64  // width / height; 3 when 0 (banners, wordmark logos)
+	Placeholders    []uint64 // perceptual hashes of known placeholder images (see Hash)
+	MaxHashDistance int      // bits a hash may differ from a placeholder and still match; 6 when 0
+}
+
+func (r Rules) withDefaults() Rules {
+	if r.MinWidth <= 0 {
+		r.MinWidth = 400
+	}
+	if r.MinHeight <= 0 {
+		r.MinHeight = 200
+	}
+	if r.MinRatio <= 0 {
+		r.MinRatio = 0.5
+	}
+	if r.MaxRatio <= 0 {
+		r.MaxRatio = 3
+	}
+	if r.MaxHashDistance <= 0 {
+		r.MaxHashDistance = 6
+	}
+	return r
+}
+
+// Check is the verdict on one candidate image
+type Check struct {
+	URL    string
+	OK     bool
+	Reason string // why the image was rejected, or what was accepted ("1200x675 jpeg")
+	Width  int
+	Height int
+	Format string
+	Hash   uint64 // perceptual hash, 0 if the image was not downloaded in full
+	Image  *Image // prepared for upload (accepted images only)
+	ByURL  bool   // accepted unchecked in a format we cannot decode (AVIF); send the URL, not an upload
+}
+
+func reject(c Check, format string, args ...interface{}) Check {
+	c.OK = false
+	c.Reason = fmt.Sprintf(format, args...)
+	return c
+}
+
+// headBytes is how much of an image is range-requested to read its header
+const headBytes = 64 << 10
+
+// minContrast is the smallest standard deviation of brightness a real photo has;
+// blank and single-color placeholders fall below it
+const minContrast = 8.0
+
+// suspiciousName matches image URLs of logos, icons and tracking pixels
+var suspiciousName = regexp.MustCompile(`(?i)(^|[/_.\-])(logo|logos|favicon|sprite|placeholder|fallback|spacer|pixel|blank|tracking|1x1)([/_.\-]|$)`)
+
+// Validate checks a candidate article image:
+//   - the URL does not look like a logo, icon or tracking pixel;
+//   - the first bytes (range request) are an image with sensible size and aspect ratio;
+//   - the full image can be prepared for Telegram (see Prepare), is not blank and
+//     does not match a known placeholder.
+//
+// Images in a format we cannot decode (AVIF, see SniffFormat) cannot be measured or hashed;
+// they are accepted with ByURL set and Telegram gets the URL.
+//
+// Accepted images keep the prepared upload in Check.Image, so the image is not downloaded twice.
+func Validate(ctx context.Context, url string, rules Rules) Check {
+	rules = rules.withDefaults()
+	c := Check{URL: url}
+
+	if m := suspiciousName.FindStringSubmatch(strings.SplitN(url, "?", 2)[0]); m != nil {
+		return reject(c, "URL looks like a %s image", strings.ToLower(m[2]))
+	}
+
+	head, complete, err := fetchHead(ctx, url)
+	if err != nil {
+		return reject(c, "%v", err)
+	}
+	if format := SniffFormat(head); format != "" {
+		c.OK, c.ByURL, c.Format = true, true, format
+		c.Reason = format + " image, passed to Telegram by URL"
+		return c
+	}
+	// A JPEG header can be pushed past the first bytes by metadata; then only the full image tells the size
+	if cfg, format, err := image.DecodeConfig(bytes.NewReader(head)); err == nil {
+		c.Width, c.Height, c.Format = cfg.Width, cfg.Height, format
+		if reason := rules.sizeProblem(cfg.Width, cfg.Height); reason != "" {
+			return reject(c, "%s", reason)
+		}
+	} else if complete {
+		return reject(c, "unsupported image (%s): %v", http.DetectContentType(head), err)
+	}
+
+	var img *Image
+	if complete {
+		img, err = Prepare(head)
+		if img != nil {
+			img.URL = url
+		}
+	} else {
+		img, err = Fetch(ctx, url)
+	}
+	if err != nil {
+		return reject(c, "%v", err)
+	}
+	if c.Format == "" {
+		c.Width, c.Height, c.Format = img.Width, img.Height, img.SourceFormat
+	}
+	// Again for images whose header was not in the first bytes (resizing keeps the aspect ratio)
+	if reason := rules.sizeProblem(img.Width, img.Height); reason != "" {
+		return reject(c, "%s", reason)
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		return reject(c, "error decoding image: %v", err)
+	}
+	var contrast float64
+	c.Hash, contrast = fingerprint(decoded)
+	if contrast < minContrast {
+		return reject(c, "blank or single-color image (contrast %.1f)", contrast)
+	}
+	for _, p := range rules.Placeholders {
+		if d := bits.OnesCount64(c.Hash ^ p); d <= rules.MaxHashDistance {
+			return reject(c, "matches placeholder image %s (distance %d)", FormatHash(p), d)
+		}
+	}
+
+	c.OK = true
+	c.Reason = fmt.Sprintf("%dx%d %s", c.Width, c.Height, c.Format)
+	c.Image = img
+	return c
+}
+
+// sizeProblem describes why an image of w x h is not a usable photo ("" if it is)
+func (r Rules) sizeProblem(w, h int) string {
+	if w <= 2 && h <= 2 {
+		return fmt.Sprintf("tracking pixel (%dx%d)", w, h)
+	}
+	if w < r.MinWidth || h < r.MinHeight {
+		return fmt.Sprintf("too small (%dx%d, need %dx%d)", w, h, r.MinWidth, r.MinHeight)
+	}
+	if ratio := float64(w) / float64(h); ratio < r.MinRatio || ratio > r.MaxRatio {
+		return fmt.Sprintf("aspect ratio %.2f outside %.2f-%.2f (%dx%d)", ratio, r.MinRatio, r.MaxRatio, w, h)
+	}
+	return ""
+}
+
+// fetchHead range-requests the first headBytes of url. complete is true when the whole image was received
+// (small image, or a server that ignores Range and sent everything within the limit).
+func fetchHead(ctx context.Context, url string) ([]byte, bool, error) {
+	client := &http.Client{Timeout: requestTimeout}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error make request: %v", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; DanishNewsBot/1.0)")
+	req.Header.Set("Accept", "image/jpeg,image/png,image/webp,image/gif;q=0.8,*/*;q=0.5")
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", headBytes-1))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("error loading image: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, false, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !acceptedContentType(contentType) {
+		return nil, false, fmt.Errorf("not an image: %s", contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, headBytes+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading image: %v", err)
+	}
+	complete := len(data) <= headBytes
+	if resp.StatusCode == http.StatusPartialContent {
+		// Content-Range: bytes 0-65535/123456
+		total := resp.Header.Get("Content-Range")
+		if i := strings.LastIndexByte(total, '/'); i >= 0 {
+			size, err := strconv.Atoi(total[i+1:])
+			complete = err == nil && size == len(data)
+		}
+	}
+	if len(data) > headBytes {
+		data = data[:headBytes]
+	}
+	return data, complete, nil
+}
+
+// fingerprint returns the difference hash (dHash) of img and the standard deviation of its brightness.
+// Resized, recompressed or slightly cropped copies of one image have hashes a few bits apart.
+func fingerprint(img image.Image) (uint64, float64) {
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	// BiLinear averages over the whole source area when shrinking, unlike ApproxBiLinear
+	draw.BiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	var sum, sumSq float64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 9; x++ {
+			v := float64(small.GrayAt(x, y).Y)
+			sum += v
+			sumSq += v * v
+			if x < 8 && small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1 << uint(y*8+x)
+			}
+		}
+	}
+	mean := sum / 72
+	return hash, math.Sqrt(math.Max(sumSq/72-mean*mean, 0))
+}
+
+// Hash returns the perceptual hash Validate compares against Rules.Placeholders
+func Hash(img image.Image) uint64 {
+	h, _ := fingerprint(img)
+	return h
+}
+
+// FormatHash renders a hash as 16 hex digits, the format of the placeholders file
+func FormatHash(h uint64) string {
+	return fmt.Sprintf("%016x", h)
+}
+
+// LoadPlaceholders reads perceptual hashes of known placeholder images, one hex hash per line;
+// text after the hash and lines starting with # are comments. A missing file is an empty list.
+func LoadPlaceholders(path string) ([]uint64, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read placeholders %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var hashes []uint64
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		h, err := strconv.ParseUint(fields[0], 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid hash %q", path, line, fields[0])
+		}
+		hashes = append(hashes, h)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read placeholders %s: %v", path, err)
+	}
+	return hashes, nil
+}
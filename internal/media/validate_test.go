@@ -0,0 +1,84 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pattern draws an image with enough structure to pass the contrast check; seed changes the picture
+func pattern(t *testing.T, w, h, seed int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8((uint32(x/50*31+y/50*17+seed) * 2654435761) >> 24)
+			img.Set(x, y, color.RGBA{R: v, G: v / 2, B: 255 - v, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidate(t *testing.T) {
+	files := map[string][]byte{
+		"/photo.png":     pattern(t, 800, 450, 3),
+		"/other.png":     pattern(t, 800, 450, 5),
+		"/t.png":         encodePNG(t, 1, 1),
+		"/blank.png":     encodePNG(t, 800, 450),
+		"/banner.png":    pattern(t, 1200, 200, 3),
+		"/site-logo.png": pattern(t, 800, 450, 3),
+		"/photo.avif":    avifHeader,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		// ServeContent answers the Range request with 206
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	check := Validate(ctx, srv.URL+"/photo.png", Rules{})
+	if !check.OK || check.Width != 800 || check.Image == nil || check.Hash == 0 {
+		t.Fatalf("photo rejected: %+v", check)
+	}
+	photoHash := check.Hash
+
+	if c := Validate(ctx, srv.URL+"/photo.avif", Rules{}); !c.OK || !c.ByURL || c.Format != "avif" || c.Image != nil {
+		t.Errorf("AVIF should be accepted by URL: %+v", c)
+	}
+
+	for path, want := range map[string]string{
+		"/t.png":         "tracking pixel",
+		"/blank.png":     "blank",
+		"/banner.png":    "aspect ratio",
+		"/site-logo.png": "logo",
+		"/missing.png":   "404",
+	} {
+		if c := Validate(ctx, srv.URL+path, Rules{}); c.OK || !strings.Contains(c.Reason, want) {
+			t.Errorf("%s: expected %q, got %+v", path, want, c)
+		}
+	}
+
+	rules := Rules{Placeholders: []uint64{photoHash}}
+	if c := Validate(ctx, srv.URL+"/photo.png", rules); c.OK || !strings.Contains(c.Reason, "placeholder") {
+		t.Errorf("expected placeholder match, got %+v", c)
+	}
+	if c := Validate(ctx, srv.URL+"/other.png", rules); !c.OK {
+		t.Errorf("a different image matched the placeholder: %+v", c)
+	}
+}
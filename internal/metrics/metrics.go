@@ -26,6 +26,34 @@ type Metrics struct {
 	LastErrorTime time.Time
 	LastError     string
 	IsHealthy     bool
+
+	// Per-feed outcome of the last fetch
+	Feeds []FeedResult
+}
+
+// Feed fetch statuses
+const (
+	FeedOK          = "ok"
+	FeedNotModified = "not_modified" // HTTP 304, items re-parsed from the stored copy
+	FeedFailed      = "error"
+	FeedParseError  = "parse_error"
+	FeedDisabled    = "disabled" // skipped after too many consecutive failures
+)
+
+// FeedResult is the outcome of fetching one feed
+type FeedResult struct {
+	Name       string
+	URL        string
+	Status     string // one of the Feed* statuses
+	HTTPStatus int
+	Items      int
+	Duration   time.Duration
+	Error      string
+
+	// Health history
+	ConsecutiveFailures int
+	LastSuccess         time.Time
+	Stale               bool // no new items for longer than the configured threshold
 }
 
 var Global = &Metrics{IsHealthy: true}
@@ -88,10 +116,34 @@ func (m *Metrics) SetError(err string) {
 	m.IsHealthy = false
 }
 
+// SetFeedResults replaces per-feed results with those of the latest fetch
+func (m *Metrics) SetFeedResults(results []FeedResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Feeds = append([]FeedResult(nil), results...)
+}
+
 func (m *Metrics) GetStats() map[string]interface{} {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	feeds := make([]map[string]interface{}, 0, len(m.Feeds))
+	for _, f := range m.Feeds {
+		feeds = append(feeds, map[string]interface{}{
+			"name":        f.Name,
+			"url":         f.URL,
+			"status":      f.Status,
+			"http_status": f.HTTPStatus,
+			"items":       f.Items,
+			"duration_ms": f.Duration.Milliseconds(),
+			"error":       f.Error,
+
+			"consecutive_failures": f.ConsecutiveFailures,
+			"last_success":         f.LastSuccess.Format(time.RFC3339),
+			"stale":                f.Stale,
+		})
+	}
+
 	return map[string]interface{}{
 		"total_news_processed":       m.TotalNewsProcessed,
 		"successful_translations":    m.SuccessfulTranslations,
@@ -104,5 +156,6 @@ func (m *Metrics) GetStats() map[string]interface{} {
 		"last_error_time":            m.LastErrorTime.Format(time.RFC3339),
 		"last_error":                 m.LastError,
 		"is_healthy":                 m.IsHealthy,
+		"feeds":                      feeds,
 	}
 }
@@ -0,0 +1,136 @@
+package news
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// SourceRef is another outlet's article about the same story
+type SourceRef struct {
+	Name string
+	Link string
+}
+
+// clusterBonusPerSource rewards stories covered by several outlets (capped at clusterBonusMax)
+const (
+	clusterBonusPerSource = 5
+	clusterBonusMax       = 15
+)
+
+// sameStory reports whether two candidates describe the same event
+func sameStory(a, b *News, maxDistance int) bool {
+	if isSimilarTitle(a.Title, b.Title) {
+		return true
+	}
+	return a.Fingerprint != 0 && b.Fingerprint != 0 && FingerprintDistance(a.Fingerprint, b.Fingerprint) <= maxDistance
+}
+
+// clusterStories groups candidates about the same event, keeps the richest article of each
+// group as primary and lists the other outlets in AlsoReportedBy. Input order is kept.
+func clusterStories(candidates []News, maxDistance int, report *Report) []News {
+	var clusters [][]int
+	for i := range candidates {
+		joined := false
+		for c, members := range clusters {
+			for _, m := range members {
+				if sameStory(&candidates[i], &candidates[m], maxDistance) {
+					clusters[c] = append(clusters[c], i)
+					joined = true
+					break
+				}
+			}
+			if joined {
+				break
+			}
+		}
+		if !joined {
+			clusters = append(clusters, []int{i})
+		}
+	}
+
+	out := make([]News, 0, len(clusters))
+	for _, members := range clusters {
+		primary := members[0]
+		for _, m := range members[1:] {
+			if richer(&candidates[m], &candidates[primary]) {
+				primary = m
+			}
+		}
+		n := candidates[primary]
+
+		seen := map[string]bool{n.SourceName: true}
+		best := n.Score
+		for _, m := range members {
+			if m == primary {
+				continue
+			}
+			other := candidates[m]
+			report.Reject(other.Link, fmt.Sprintf("same story as %q (%s)", n.Title, n.SourceName))
+			if other.Score > best {
+				best = other.Score
+			}
+			if other.SourceName == "" || seen[other.SourceName] {
+				continue
+			}
+			seen[other.SourceName] = true
+			n.AlsoReportedBy = append(n.AlsoReportedBy, SourceRef{Name: other.SourceName, Link: other.Link})
+		}
+
+		// The story ranks like its best-scored article plus a bonus for independent coverage
+		if len(n.AlsoReportedBy) > 0 || best > n.Score {
+			scoring := ScoreResult{}
+			if n.Scoring != nil {
+				scoring = *n.Scoring
+			}
+			if best > n.Score {
+				scoring.Steps = append(scoring.Steps, ScoreStep{Rule: "best score in story cluster", Delta: best - n.Score})
+			}
+			bonus := clusterBonusPerSource * len(n.AlsoReportedBy)
+			if bonus > clusterBonusMax {
+				bonus = clusterBonusMax
+			}
+			if bonus > 0 {
+				scoring.Steps = append(scoring.Steps, ScoreStep{Rule: fmt.Sprintf("reported by %d more outlets", len(n.AlsoReportedBy)), Delta: bonus})
+			}
+			n.Score = best + bonus
+			scoring.Score = n.Score
+			n.Scoring = &scoring
+			report.setScore(n.Link, scoring)
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// richer prefers the article with more text, then one with an image, then the higher score
+func richer(a, b *News) bool {
+	la, lb := utf8.RuneCountInString(a.Content), utf8.RuneCountInString(b.Content)
+	if la != lb {
+		return la > lb
+	}
+	if (a.ImageURL != "") != (b.ImageURL != "") {
+		return a.ImageURL != ""
+	}
+	return a.Score > b.Score
+}
+
+// maxAlsoReportedBy limits how many outlets are linked so captions stay short
+const maxAlsoReportedBy = 3
+
+// formatAlsoReportedBy renders other outlets as Telegram HTML links in n.Layout's language ("" when there are none)
+func formatAlsoReportedBy(n News) string {
+	refs := n.AlsoReportedBy
+	if len(refs) == 0 {
+		return ""
+	}
+	if len(refs) > maxAlsoReportedBy {
+		refs = refs[:maxAlsoReportedBy]
+	}
+	links := make([]string, 0, len(refs))
+	for _, s := range refs {
+		links = append(links, `<a href="`+html.EscapeString(s.Link)+`">`+html.EscapeString(s.Name)+`</a>`)
+	}
+	return n.Layout.Pick("📰 Også hos / Також: ", "📰 Også hos: ", "📰 Також: ") + strings.Join(links, ", ")
+}
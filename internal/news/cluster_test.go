@@ -0,0 +1,41 @@
+package news
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterStories(t *testing.T) {
+	mk := func(source, link, title, content string, score int) News {
+		return News{SourceName: source, Link: link, Title: title, Content: content, Score: score, Fingerprint: Fingerprint(title, content)}
+	}
+	candidates := []News{
+		mk("DR", "https://dr.dk/1", "Storbrand i Aarhus: Beboere evakueret i nat", "Kort.", 60),
+		mk("Ekstra Bladet", "https://eb.dk/2", "Danske Bank hæver renten", "Banken hæver renten på boliglån fra næste måned.", 40),
+		mk("TV2", "https://tv2.dk/3", "Storbrand i Aarhus: Beboere evakueret i nat", "En stor brand i et boligkompleks i Aarhus har tvunget beboerne ud om natten.", 50),
+		mk("DR", "https://dr.dk/4", "Storbrand i Aarhus: beboere evakueret", "Samme historie fra DR igen.", 55),
+	}
+
+	got := clusterStories(candidates, 12, nil)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 stories, got %d", len(got))
+	}
+	fire := got[0]
+	if fire.SourceName != "TV2" {
+		t.Errorf("primary should be the richest article (TV2), got %s", fire.SourceName)
+	}
+	if len(fire.AlsoReportedBy) != 1 || fire.AlsoReportedBy[0].Name != "DR" {
+		t.Errorf("also reported by: %+v", fire.AlsoReportedBy)
+	}
+	if fire.Score != 60+clusterBonusPerSource {
+		t.Errorf("cluster score %d, want best score plus bonus %d", fire.Score, 60+clusterBonusPerSource)
+	}
+	if got[1].SourceName != "Ekstra Bladet" || len(got[1].AlsoReportedBy) != 0 {
+		t.Errorf("unrelated story changed: %+v", got[1])
+	}
+
+	text := FormatNewsWithImage(fire, 2, 2)
+	if !strings.Contains(text, `<a href="https://dr.dk/1">DR</a>`) {
+		t.Errorf("message lacks also-reported-by link:\n%s", text)
+	}
+}
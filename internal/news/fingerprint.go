@@ -0,0 +1,125 @@
+package news
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// fingerprintStopWords are skipped when fingerprinting (Danish/English function words)
+var fingerprintStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "that": true, "this": true,
+	"og": true, "til": true, "med": true, "der": true, "det": true, "den": true,
+	"som": true, "har": true, "ikke": true, "efter": true, "over": true, "fra": true, "nu": true,
+	"skal": true, "vil": true, "kan": true, "blev": true, "bliver": true, "være": true, "alle": true,
+}
+
+// fingerprintStem is how many leading runes of a word are kept, so inflections
+// ("ukrainere", "ukrainske") hash to the same feature
+const fingerprintStem = 6
+
+// Fingerprint returns a 64-bit SimHash of the story's words; title words weigh double.
+// Rewordings of the same story differ in few bits (see FingerprintDistance).
+func Fingerprint(title, description string) uint64 {
+	var weights [64]int
+	add := func(text string, weight int) {
+		for _, w := range fingerprintWords(text) {
+			h := fnv.New64a()
+			h.Write([]byte(w))
+			sum := h.Sum64()
+			for bit := 0; bit < 64; bit++ {
+				if sum&(1<<uint(bit)) != 0 {
+					weights[bit] += weight
+				} else {
+					weights[bit] -= weight
+				}
+			}
+		}
+	}
+	add(title, 2)
+	add(description, 1)
+
+	var fp uint64
+	for bit, w := range weights {
+		if w > 0 {
+			fp |= 1 << uint(bit)
+		}
+	}
+	return fp
+}
+
+// FingerprintDistance is the number of differing bits between two fingerprints (0..64)
+func FingerprintDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// fingerprintWords normalizes text into stemmed words without stop words and HTML
+func fingerprintWords(text string) []string {
+	text = stripHTMLTags(strings.ToLower(text))
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	out := make([]string, 0, len(words))
+	for _, w := range words {
+		if len([]rune(w)) < 3 || fingerprintStopWords[w] {
+			continue
+		}
+		if r := []rune(w); len(r) > fingerprintStem {
+			w = string(r[:fingerprintStem])
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+func stripHTMLTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+			b.WriteRune(' ')
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// SentStory is a previously posted story used for cross-run duplicate detection
+type SentStory struct {
+	Title       string
+	Link        string
+	Fingerprint uint64 // 0 for records stored before fingerprints existed
+	SentAt      time.Time
+	MessageID   int64  // Telegram message_id (0 if unknown)
+	Content     string // feed description as posted
+}
+
+// findSentDuplicate returns the sent story that an item repeats (same link, a fingerprint
+// within maxDistance bits or a similar title) or nil
+func findSentDuplicate(sent []SentStory, link, title string, fp uint64, maxDistance int) *SentStory {
+	for i := range sent {
+		s := &sent[i]
+		if s.Link != "" && normalizeURL(s.Link) == normalizeURL(link) {
+			return s
+		}
+		if s.Fingerprint != 0 && FingerprintDistance(s.Fingerprint, fp) <= maxDistance {
+			return s
+		}
+		if isSimilarTitle(s.Title, title) {
+			return s
+		}
+	}
+	return nil
+}
+
+// SentDuplicate returns the story in sent that n repeats (same link, close fingerprint or similar title), nil if none
+func SentDuplicate(n News, sent []SentStory, maxDistance int) *SentStory {
+	return findSentDuplicate(sent, n.Link, n.Title, n.Fingerprint, maxDistance)
+}
@@ -0,0 +1,30 @@
+package news
+
+import "testing"
+
+func TestFindSentDuplicate(t *testing.T) {
+	sent := []SentStory{
+		{
+			Title:       "Regeringen vil forlænge opholdstilladelser for ukrainere",
+			Link:        "https://www.dr.dk/nyheder/politik/ophold",
+			Fingerprint: Fingerprint("Regeringen vil forlænge opholdstilladelser for ukrainere", "Ukrainske flygtninge i Danmark kan se frem til, at deres opholdstilladelse bliver forlænget med et år, oplyser regeringen."),
+		},
+		{Title: "Storbrand i Aarhus: Beboere evakueret i nat"}, // stored before fingerprints
+	}
+
+	tests := []struct {
+		name, link, title, description string
+		dup                            bool
+	}{
+		{"same link with tracking", "https://www.dr.dk/nyheder/politik/ophold?utm_source=rss", "Anden overskrift", "", true},
+		{"reworded by another outlet", "https://ekstrabladet.dk/a", "Ukrainere får forlænget opholdstilladelse", "Regeringen forlænger opholdstilladelser for ukrainske flygtninge i Danmark med endnu et år.", true},
+		{"similar title without fingerprint", "https://tv2.dk/b", "Storbrand i Aarhus: Beboere evakueret i nat", "", true},
+		{"different story", "https://tv2.dk/c", "Danske Bank hæver renten", "Banken hæver renten på boliglån fra næste måned.", false},
+	}
+	for _, tt := range tests {
+		got := findSentDuplicate(sent, tt.link, tt.title, Fingerprint(tt.title, tt.description), 12)
+		if (got != nil) != tt.dup {
+			t.Errorf("%s: duplicate = %v, want %v", tt.name, got != nil, tt.dup)
+		}
+	}
+}
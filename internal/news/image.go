@@ -0,0 +1,41 @@
+package news
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/deusflow/News/internal/media"
+)
+
+// validateImages checks the images of the selected items in parallel (see media.Validate) and records
+// the verdict in News.Image; PhotoDecision then keeps items with a rejected image out of photo mode.
+// The prepared upload (up to media.MaxPhotoBytes per item) is kept only when keep is set.
+func validateImages(ctx context.Context, items []News, rules media.Rules, concurrency int, keep bool) {
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range items {
+		if strings.TrimSpace(items[i].ImageURL) == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(n *News) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			check := media.Validate(ctx, n.ImageURL, rules)
+			if !keep {
+				check.Image = nil
+			}
+			n.Image = &check
+			if check.OK {
+				log.Printf("🖼️ Image OK (%s): %s", check.Reason, n.Title)
+			} else {
+				log.Printf("🚫 Image rejected (%s): %s", check.Reason, n.ImageURL)
+			}
+		}(&items[i])
+	}
+	wg.Wait()
+}
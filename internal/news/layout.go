@@ -0,0 +1,59 @@
+package news
+
+import "strings"
+
+// Layout picks the languages a post is rendered in
+type Layout string
+
+const (
+	LayoutBilingual Layout = "bilingual" // Danish, then Ukrainian (default)
+	LayoutUkrainian Layout = "uk-only"
+	LayoutDanish    Layout = "da-only"
+)
+
+// Pick returns the variant of a fixed label (header, footer) in the layout's language
+func (l Layout) Pick(bilingual, danish, ukrainian string) string {
+	switch l {
+	case LayoutUkrainian:
+		return ukrainian
+	case LayoutDanish:
+		return danish
+	default:
+		return bilingual
+	}
+}
+
+// langBlock is one language section of a post: flag, title and (not yet condensed) summary
+type langBlock struct {
+	flag    string
+	title   string
+	summary string
+}
+
+// langBlocks returns the sections of n in posting order according to n.Layout
+func langBlocks(n News) []langBlock {
+	daTitle := strings.TrimSpace(n.Title)
+	ukTitle := strings.TrimSpace(n.TitleUkrainian)
+	if ukTitle == "" {
+		ukTitle = daTitle
+	}
+	daSum := strings.TrimSpace(n.SummaryDanish)
+	if daSum == "" {
+		daSum = fallbackSummary(n.Content)
+	}
+	ukSum := strings.TrimSpace(n.SummaryUkrainian)
+	if ukSum == "" {
+		ukSum = fallbackSummary(n.Content)
+	}
+
+	da := langBlock{flag: "🇩🇰", title: daTitle, summary: daSum}
+	uk := langBlock{flag: "🇺🇦", title: ukTitle, summary: ukSum}
+	switch n.Layout {
+	case LayoutUkrainian:
+		return []langBlock{uk}
+	case LayoutDanish:
+		return []langBlock{da}
+	default:
+		return []langBlock{da, uk}
+	}
+}
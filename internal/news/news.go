@@ -1,19 +1,22 @@
 package news
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
 	"log"
+	"math"
 	"net/url"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
 	"unicode/utf8"
 
-	"github.com/deusflow/News/internal/gemini"
+	"github.com/deusflow/News/internal/media"
 	"github.com/deusflow/News/internal/metrics"
 	"github.com/deusflow/News/internal/rss"
 	"github.com/deusflow/News/internal/scraper"
@@ -22,12 +25,13 @@ import (
 
 // News represents a single news item enriched by AI summaries with image support.
 type News struct {
-	Title     string
-	Content   string
-	Link      string
-	Published time.Time
-	Category  string
-	Score     int
+	Title       string
+	Content     string
+	Description string // feed description (Content is replaced by the scraped article)
+	Link        string
+	Published   time.Time
+	Category    string
+	Score       int
 
 	SourceName       string
 	SourceLang       string
@@ -39,168 +43,78 @@ type News struct {
 	TitleUkrainian   string // Ukrainian title (translated from Title)
 
 	// Image support - добавляем поддержку изображений
-	ImageURL string // URL изображения новости
-	ImageAlt string // Альтернативный текст для изображения
-}
+	ImageURL string       // URL изображения новости
+	ImageAlt string       // Альтернативный текст для изображения
+	Image    *media.Check // validation of ImageURL (nil = not checked); accepted images carry the prepared upload with Options.KeepImages
 
-// Extra boost keywords for refugee/visa related stories to increase priority
-var refugeeBoostKeywords = []string{
-	"refugee",
-	"viborg",
-	"flygtning",
-	"refugee visa",
-	"temporary protection",
-	"asylum",
-	"asylum support",
-	"asylum application",
-	"asylum application form",
-	"asylum application form ukraine",
-	"asylum application form denmark",
-	"families",
-	"family",
-}
+	Scoring     *ScoreResult // why the item got its category and score
+	Fingerprint uint64       // SimHash of title and description for cross-run duplicate detection
 
-var visaBoostKeywords = []string{
-	"visum",
-	"visumforlængelse",
-	"opholdstilladelse",
-	"blive i EU",
-}
-
-// Географические / "украинские" термины (про саму Украину и украинцев)
-var ukraineGeoKeywords = []string{
-	"ukraine", "ukraina", "ukrainer", "ukrainsk", "ukrainere", "ukrainske",
-	"ukrainske familier", "ukrainske i danmark", "ukrainere i danmark",
-	"ukrainsk diaspora", "flygtninge fra ukraine",
-}
-
-var denmarkKeywords = []string{
-	"danmark", "danske", "københavn", "aarhus", "aalborg", "viborg",
-	"region", "kommune", "borgere", "lov", "politik", "økonomi",
-	"visum", "opholdstilladelse", "asyl", "integration", "arbejde", "bolig",
-	"udlændinge",
-}
-
-var conflictKeywords = []string{
-	"krig", "krigen", "putin", "zelensky", "invasion", "bomb", "missil", "russisk", "war", "invasion",
-}
+	AlsoReportedBy []SourceRef // other outlets covering the same story (see clusterStories)
 
-// Технологии / инновации / стартапы / исследования
-var techKeywords = []string{
-	"teknologi", "innovation", "startup", "forskning", "research", "patent",
-	"robot", "software", "hardware", "IT", "cloud", "cyber", "data",
-	"machine learning", "deep learning", "artificial intelligence", "AI", "maskinlæring", "LLM",
+	Layout Layout // languages to render the post in; empty means bilingual
 }
 
-// Исключительно AI-термины (чтобы точно поймать ИИ-новости)
-var aiKeywords = []string{
-	"ai", "artificial intelligence", "maskinlæring", "neuralt netværk", "large language model", "llm",
+// ScoreResult explains the scoring of one feed item: matched keywords by set, every step
+// that added to the score and, for dropped items, why
+type ScoreResult struct {
+	Title    string              `json:"title"`
+	Link     string              `json:"link"`
+	Source   string              `json:"source,omitempty"`
+	Category string              `json:"category"`
+	Score    int                 `json:"score"`
+	Matched  map[string][]string `json:"matched,omitempty"`
+	Steps    []ScoreStep         `json:"steps,omitempty"`
+	Rejected string              `json:"rejected,omitempty"` // exclusion, duplicate, feed gating, ...
+	Selected bool                `json:"selected,omitempty"` // picked for summarizing (pipeline report only)
 }
 
-// Медицинские / фармацевтические темы
-var medicalKeywords = []string{
-	"lægemidler", "medicin", "vaccine", "klinisk forsøg", "pharma", "biotek", "behandling", "treatment",
+// ScoreStep is one term of the score: category base, keyword boost or penalty, feed weighting
+type ScoreStep struct {
+	Rule  string `json:"rule"`
+	Delta int    `json:"delta"`
 }
 
-// Words to exclude (not important topics)
-var excludeKeywords = []string{
-	"vejr",
-	"musik",
-	"film",
-	"kendis",
-	"fodboldresultat",
-	"sportsresultat",
-	"tv-program",
-	"horoskop",
-	"madopskrift",
-}
-
-// Европа / европейский контекст (шире чем Дания)
-var europeKeywords = []string{
-	"europa", "eu", "european", "eu-lande", "europeisk",
-}
-
-// Тематики для подростков и родителей
-var youthKeywords = []string{
-	"ungdom", "teenager", "unge", "skole", "gymnasium", "uddannelse", "studerende",
-	"fritid", "sport", "gaming", "esport", "social media", "mobil", "app",
-	"musik", "festival", "koncert", "streaming", "youtube", "tiktok", "instagram",
-	"snapchat", "discord", "twitch", "netflix", "spotify", "podcast",
-	"mode", "influencer", "blogger", "vlogger", "content creator",
-	"mental sundhed", "stress", "angst", "selvværd", "mobning", "cybermobning",
-	"kæreste", "venskab", "dating", "ungdomskultur", "trend", "viral",
-	"uddannelsesvalg", "studievejledning", "efterskole", "gap year",
-	"job", "praktikplads", "sommerjob", "ungdomsarbejde", "cv",
-}
-
-var parentKeywords = []string{
-	"forældre", "børn", "familie", "dagpleje", "børnehave", "skole", "mor", "far",
-	"graviditet", "fødsel", "baby", "småbørn", "teenager", "opdragelse", "familieøkonomi",
-	"børnepenge", "orlov", "barsel", "familieydelse", "SFO", "fritidsordning",
-	"mødregruppe", "fædregruppe", "forældremøde", "forældreinddragelse",
-	"børns udvikling", "motorik", "sprog", "læsning", "matematik",
-	"allergi", "astma", "vaccination", "sundhedspleje", "børnelæge",
-	"skilsmisse", "samvær", "børnebidrag", "forældremyndighed",
-	"digital opdragelse", "skærmtid", "online sikkerhed", "cybersikkerhed",
-	"bullying", "mobning", "skolevægring", "særlige behov", "inklusion",
-	"familieaktiviteter", "ferie", "børnevenlig", "legeplads", "zoo", "museum",
-	"boligsøgning", "børnevenlig bolig", "sikkerhed hjemme", "babyproofing",
+// Summary renders the result on one line for logs
+func (r ScoreResult) Summary() string {
+	parts := make([]string, 0, len(r.Steps))
+	for _, s := range r.Steps {
+		parts = append(parts, fmt.Sprintf("%s %+d", s.Rule, s.Delta))
+	}
+	out := fmt.Sprintf("%s %d", r.Category, r.Score)
+	if r.Rejected != "" {
+		out = "rejected: " + r.Rejected
+	}
+	if len(parts) > 0 {
+		out += " [" + strings.Join(parts, ", ") + "]"
+	}
+	return out
 }
 
-var culturalKeywords = []string{
-	"kultur", "museum", "teater", "opera", "kunst", "udstilling", "galleri",
-	"litteratur", "bog", "forfatter", "bibliotek", "kulturel", "traditions",
-	"folkefest", "festival", "kulturnat", "kunstmuseum", "kulturhus",
-	"dansk kultur", "historie", "arv", "traditioner", "kulturformidling",
-	"scene", "skuespil", "ballet", "koncert", "klassisk musik", "jazz",
-	"film", "documentary", "kortfilm", "filminstruktør", "dansk film",
-	"design", "arkitektur", "møbler", "dansk design", "designmuseum",
-}
+// ScoreItem runs the same scoring as the pipeline and explains the outcome
+func ScoreItem(item *rss.FeedItem) ScoreResult {
+	res := newScoreResult(item)
+	rs := currentRules()
+	if rs == nil {
+		res.Rejected = "scoring rules not loaded"
+		return res
+	}
 
-var sportsKeywords = []string{
-	"sport", "fodbold", "håndbold", "cykling", "svømning", "atletik", "fitness",
-	"idræt", "konkurrence", "mesterskab", "olympiske", "VM", "EM",
-	"badminton", "tennis", "basketball", "volleyball", "gymnastik",
-	"løb", "marathon", "triathlon", "styrketræning", "crossfit",
-	"børnesport", "ungdomsidræt", "idrætsforening", "klub", "hold",
-	"sundhed", "motion", "aktiv", "træning", "coaching", "instruktør",
-	"parasport", "handicapidræt", "inklusion i sport", "tilgængelighed",
+	text := item.Title + " " + item.Description
+	res.Matched = rs.matchedKeywords(text, itemLang(item))
+	rs.explain(text, itemLang(item), &res)
+	if res.Score > 0 && item.Source != nil {
+		applySourceRules(item.Source, &res)
+	}
+	return res
 }
 
-// improved containsAny: distinguishes phrases and short words (avoids "ai" matching "said")
-func containsAny(text string, keywords []string) bool {
-	text = strings.ToLower(text)
-
-	for _, k := range keywords {
-		k = strings.ToLower(strings.TrimSpace(k))
-		if k == "" {
-			continue
-		}
-
-		// If keyword is a phrase (contains space) -> substring match
-		if strings.Contains(k, " ") {
-			if strings.Contains(text, k) {
-				return true
-			}
-			continue
-		}
-
-		// Short tokens (<=3) -> whole word match using word boundary regexp
-		if len(k) <= 3 {
-			// Use regexp.QuoteMeta to avoid accidental meta-chars in keyword
-			re := regexp.MustCompile(`\b` + regexp.QuoteMeta(k) + `\b`)
-			if re.MatchString(text) {
-				return true
-			}
-			continue
-		}
-
-		// Otherwise, simple substring is fine
-		if strings.Contains(text, k) {
-			return true
-		}
+func newScoreResult(item *rss.FeedItem) ScoreResult {
+	res := ScoreResult{Title: item.Title, Link: item.Link}
+	if item.Source != nil {
+		res.Source = item.Source.Name
 	}
-	return false
+	return res
 }
 
 // makeNewsKey generates a hash key from title and description for deduplication
@@ -320,182 +234,105 @@ func makeSimilarityKey(item *rss.FeedItem) string {
 	return key
 }
 
-// calculateNewsScore - переработанная логика приоритезации
+// calculateNewsScore scores an item by keywords, then applies its feed's category rules and weight
 func calculateNewsScore(item *rss.FeedItem) (string, int) {
-	text := strings.ToLower(item.Title + " " + item.Description)
-
-	// Быстрая фильтрация
-	if containsAny(text, excludeKeywords) {
-		return "", 0
-	}
-
-	// Флаги
-	hasDenmark := containsAny(text, denmarkKeywords)
-	hasUkraineGeo := containsAny(text, ukraineGeoKeywords)
-	hasEurope := containsAny(text, europeKeywords)
-	hasTech := containsAny(text, techKeywords)
-	hasMedical := containsAny(text, medicalKeywords)
-	hasConflict := containsAny(text, conflictKeywords)
-	hasRefugeeBoost := containsAny(text, refugeeBoostKeywords)
-	hasVisaBoost := containsAny(text, visaBoostKeywords)
-	hasYouth := containsAny(text, youthKeywords)
-	hasParent := containsAny(text, parentKeywords)
-	hasCultural := containsAny(text, culturalKeywords)
-	hasSports := containsAny(text, sportsKeywords)
-
-	ctxLocal := hasDenmark || hasUkraineGeo || hasEurope
-
-	// Если это только "международное" упоминание войны/Путин без локального контекста — пропускаем
-	if hasConflict && !ctxLocal {
-		return "", 0
-	}
-
-	// Переменные результата
-	var category string
-	score := 0
-
-	// 1) Новости про украинцев / проблемы беженцев / визы — высокая приоритетность
-	if hasUkraineGeo || hasRefugeeBoost || hasVisaBoost {
-		category = "ukraine"
-		score = 70
-		if hasDenmark {
-			score += 15
-		}
-		if hasEurope {
-			score += 5
-		}
-		if hasConflict && !(hasRefugeeBoost || hasVisaBoost || hasDenmark) {
-			score -= 15
-		}
-		if hasTech {
-			score += 10
-		}
-		if hasMedical {
-			score += 10
-		}
-		return category, score
-	}
+	res := ScoreItem(item)
+	return res.Category, res.Score
+}
 
-	// 2) Технологии/медицина — требуем гео-контекст
-	if hasTech || hasMedical {
-		if !ctxLocal {
-			return "", 0
-		}
-		if hasMedical {
-			category = "health"
-		} else {
-			category = "tech"
-		}
-		score = 80
-		if containsAny(text, aiKeywords) {
-			score += 10
-		}
-		if hasDenmark {
-			score += 10
-		}
-		if hasEurope {
-			score += 5
-		}
-		return category, score
-	}
+// feedCategoryAliases maps topic names used in feeds.yaml to scoring categories
+// (refugee and visa stories are scored as "ukraine")
+var feedCategoryAliases = map[string]string{
+	"technology":  "tech",
+	"visas":       "ukraine",
+	"immigration": "ukraine",
+	"integration": "ukraine",
+}
 
-	// 3) Семья/родители (до общего датского блока, чтобы не было unreachable бонусов)
-	if hasParent && ctxLocal {
-		category = "family"
-		score = 55
-		if hasDenmark {
-			score += 10
+// applySourceRules drops categories the feed doesn't allow and weights the score by feed
+// priority (0.8x at 0 to 1.2x at 100, unset is neutral), the feed's weight and its declared topics
+func applySourceRules(src *rss.FeedSource, res *ScoreResult) {
+	category := res.Category
+	if len(src.AllowedCategories) > 0 && !hasCategory(src.AllowedCategories, category) {
+		res.Category, res.Score = "", 0
+		res.Rejected = fmt.Sprintf("feed %s does not allow category %s", src.Name, category)
+		return
+	}
+	if hasCategory(src.BlockedCategories, category) {
+		res.Category, res.Score = "", 0
+		res.Rejected = fmt.Sprintf("feed %s blocks category %s", src.Name, category)
+		return
+	}
+
+	// Each factor is recorded as the change of the rounded score so the steps add up
+	value := float64(res.Score)
+	apply := func(rule string, factor float64) {
+		before := int(math.Round(value))
+		value *= factor
+		if d := int(math.Round(value)) - before; d != 0 {
+			res.Steps = append(res.Steps, ScoreStep{Rule: rule, Delta: d})
 		}
-		return category, score
 	}
-
-	// 4) Молодежные темы
-	if hasYouth && ctxLocal {
-		category = "youth"
-		score = 50
-		if hasDenmark {
-			score += 8
-		}
-		return category, score
+	if src.Priority > 0 {
+		apply(fmt.Sprintf("feed priority %d (x%.2f)", src.Priority, priorityFactor(src.Priority)), priorityFactor(src.Priority))
 	}
-
-	// 5) Культура
-	if hasCultural && ctxLocal {
-		category = "culture"
-		score = 35
-		if hasDenmark {
-			score += 10
-		}
-		return category, score
+	if src.Weight > 0 {
+		apply(fmt.Sprintf("feed weight x%.2f", src.Weight), src.Weight)
 	}
-
-	// 6) Спорт
-	if hasSports && ctxLocal {
-		category = "sports"
-		score = 30
-		if hasDenmark {
-			score += 8
-		}
-		return category, score
+	if hasCategory(src.Categories, category) {
+		apply("feed declares "+category+" (x1.10)", 1.1)
 	}
-
-	// 7) Общие датские новости
-	if hasDenmark {
-		category = "denmark"
-		score = 40
-		if containsAny(text, []string{"politik", "regering", "økonomi", "minister"}) {
-			score += 15
-		}
-		return category, score
+	weighted := int(math.Round(value))
+	if weighted < 1 {
+		// a low weight demotes an item, it doesn't reject it
+		res.Steps = append(res.Steps, ScoreStep{Rule: "minimum score", Delta: 1 - weighted})
+		weighted = 1
 	}
+	res.Score = weighted
+}
 
-	// 8) Общие европейские новости (без датского контекста)
-	if hasEurope {
-		category = "europe"
-		score = 25
-		return category, score
+func priorityFactor(priority int) float64 {
+	if priority <= 0 {
+		return 1
 	}
-
-	// 9) Чисто конфликтные новости (минимальный приоритет)
-	if hasConflict {
-		category = "conflict"
-		score = 15
-		return category, score
+	if priority > 100 {
+		priority = 100
 	}
+	return 0.8 + 0.4*float64(priority)/100
+}
 
-	// 10) Общие категории
-	if containsAny(text, []string{"økonomi", "business", "marked", "aktier", "bank"}) {
-		category = "economy"
-		score = 20
-	} else if containsAny(text, []string{"miljø", "klima", "climate", "environment", "grøn"}) {
-		category = "environment"
-		score = 25
-	} else if containsAny(text, []string{"uddannelse", "education", "universitet"}) {
-		category = "education"
-		score = 22
-	} else if containsAny(text, []string{"europa", "european", "eu"}) {
-		category = "general"
-		score = 10
+// hasCategory reports whether list (feeds.yaml names, aliases allowed) contains category
+func hasCategory(list []string, category string) bool {
+	for _, c := range list {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if alias, ok := feedCategoryAliases[c]; ok {
+			c = alias
+		}
+		if c == category {
+			return true
+		}
 	}
+	return false
+}
 
-	if category == "" || score == 0 {
-		return "", 0
+func itemLang(item *rss.FeedItem) string {
+	if item.Source == nil {
+		return ""
 	}
-
-	return category, score
+	return strings.ToLower(strings.TrimSpace(item.Source.Lang))
 }
 
-// Gemini client injection
-var aiClient *gemini.Client
+// AI provider registry injection
+var aiRegistry *translate.Registry
 
-// SetGeminiClient sets the Gemini client for translation and summarization
-func SetGeminiClient(c *gemini.Client) {
-	aiClient = c
+// SetAIRegistry sets the provider chain used for article processing, summaries and translation
+func SetAIRegistry(r *translate.Registry) {
+	aiRegistry = r
 }
 
 // FilterAndTranslate: фильтр + скрапинг + саммаризация Gemini + мультиязычные саммари.
-func FilterAndTranslate(items []*rss.FeedItem) ([]News, error) {
-	return FilterAndTranslateWithOptions(items, Options{})
+func FilterAndTranslate(ctx context.Context, items []*rss.FeedItem) ([]News, error) {
+	return FilterAndTranslateWithOptions(ctx, items, Options{})
 }
 
 // Options controls filtering and selection behavior.
@@ -507,20 +344,50 @@ type Options struct {
 	MaxGeminiRequests int           // maximum Gemini requests allowed (0 = unlimited)
 	ScrapeMaxArticles int           // how many articles to fetch full content for (cap)
 	ScrapeConcurrency int           // parallelism for scraping full content
+	BatchSize         int           // articles per AI request when the provider supports batching (<=1 = one by one)
+	ImageRules        *media.Rules  // check the images of translated items before photo mode is chosen (nil = off)
+	KeepImages        bool          // keep the prepared upload of accepted images (UPLOAD_PHOTOS); otherwise only the verdict
+	Report            *Report       // receives the scoring decision for every item (nil = off)
+
+	// Cross-run duplicates: stories already posted and how many fingerprint bits may differ
+	// for a candidate to still count as the same story (0 = default 12)
+	Sent                   []SentStory
+	MaxFingerprintDistance int
+
+	// Targets selects candidates per posting channel: each picks its own diverse top Limit among
+	// the items it accepts, and the union is scraped and summarized once. Empty = one selection of Limit.
+	Targets []Target
+}
+
+// Target is one posting channel's share of the selection
+type Target struct {
+	Limit  int                                   // items the channel may post
+	Allows func(category string, score int) bool // nil accepts everything
+	Sent   []SentStory                           // stories the channel already got
 }
 
 // FilterAndTranslateWithOptions performs filtering and summarization using provided options.
-func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News, error) {
+// Cancelling ctx aborts in-flight scraping and AI requests and returns ctx's error.
+func FilterAndTranslateWithOptions(ctx context.Context, items []*rss.FeedItem, opts Options) ([]News, error) {
 	startTime := time.Now()
 	defer func() {
 		metrics.Global.RecordProcessingTime(time.Since(startTime))
 		metrics.Global.SetLastRun()
 	}()
 
-	if aiClient == nil {
-		return nil, fmt.Errorf("gemini client not initialized; call news.SetGeminiClient")
+	if aiRegistry == nil {
+		return nil, fmt.Errorf("AI registry not initialized; call news.SetAIRegistry")
+	}
+	if currentRules() == nil {
+		return nil, fmt.Errorf("scoring rules not loaded; call news.LoadRules")
 	}
-	log.Println("[Gemini] Starting filter + scrape + summarize pipeline (WithOptions)")
+	newsProvider := aiRegistry.NewsProvider()
+	if newsProvider == nil {
+		return nil, fmt.Errorf("no AI provider can process whole articles (configured: %v)", aiRegistry.Names())
+	}
+	// Fallback chain must not hit the same provider that just failed or ran out of budget
+	fallback := aiRegistry.Without(newsProvider.Name())
+	log.Printf("[%s] Starting filter + scrape + summarize pipeline (WithOptions)", newsProvider.Name())
 
 	// defaults
 	if opts.Limit <= 0 {
@@ -535,20 +402,26 @@ func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News,
 	if opts.PerCategory <= 0 {
 		opts.PerCategory = 2
 	}
+	if opts.MaxFingerprintDistance <= 0 {
+		opts.MaxFingerprintDistance = 12
+	}
 
 	seenLinks := map[string]struct{}{}
 	seenContent := map[string]struct{}{}
 	seenSimilar := map[string]struct{}{}
-	var seenTitles []string
 	var candidates []News
 
 	log.Printf("Начинаем фильтрацию из %d новостей (maxAge=%s)", len(items), opts.MaxAge)
 
 	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		metrics.Global.IncrementNewsProcessed()
 
 		// Ограничиваем обработку по возрасту
 		if item.PublishedParsed != nil && time.Since(*item.PublishedParsed) > opts.MaxAge {
+			opts.Report.reject(newScoreResult(item), fmt.Sprintf("older than %s", opts.MaxAge))
 			continue
 		}
 
@@ -556,6 +429,7 @@ func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News,
 		normalizedLink := normalizeURL(item.Link)
 		if _, dup := seenLinks[normalizedLink]; dup {
 			metrics.Global.IncrementDuplicatesFiltered()
+			opts.Report.reject(newScoreResult(item), "duplicate link")
 			continue
 		}
 		seenLinks[normalizedLink] = struct{}{}
@@ -564,6 +438,7 @@ func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News,
 		key := makeNewsKey(item.Title, item.Description)
 		if _, dup := seenContent[key]; dup {
 			metrics.Global.IncrementDuplicatesFiltered()
+			opts.Report.reject(newScoreResult(item), "duplicate title and description")
 			continue
 		}
 		seenContent[key] = struct{}{}
@@ -572,28 +447,26 @@ func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News,
 		similarKey := makeSimilarityKey(item)
 		if _, dup := seenSimilar[similarKey]; dup {
 			metrics.Global.IncrementDuplicatesFiltered()
+			opts.Report.reject(newScoreResult(item), "duplicate story from the same site")
 			continue
 		}
 		seenSimilar[similarKey] = struct{}{}
 
-		// Дополнительная проверка схожести заголовков с уже добавленными
-		skipSimilar := false
-		for _, existingTitle := range seenTitles {
-			if isSimilarTitle(item.Title, existingTitle) {
-				metrics.Global.IncrementDuplicatesFiltered()
-				skipSimilar = true
-				break
-			}
-		}
-		if skipSimilar {
+		// Та же история уже отправлялась в прошлых запусках (другим источником или переформулированная)
+		fingerprint := Fingerprint(item.Title, item.Description)
+		if sent := findSentDuplicate(opts.Sent, item.Link, item.Title, fingerprint, opts.MaxFingerprintDistance); sent != nil {
+			metrics.Global.IncrementDuplicatesFiltered()
+			opts.Report.reject(newScoreResult(item), fmt.Sprintf("already sent as %q at %s", sent.Title, sent.SentAt.Format("2006-01-02 15:04")))
 			continue
 		}
 
 		// Категория и скор
-		category, score := calculateNewsScore(item)
-		if score == 0 {
+		scoring := ScoreItem(item)
+		opts.Report.add(scoring)
+		if scoring.Score == 0 {
 			continue
 		}
+		category, score := scoring.Category, scoring.Score
 
 		published := time.Now()
 		if item.PublishedParsed != nil {
@@ -611,6 +484,7 @@ func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News,
 		candidates = append(candidates, News{
 			Title:            item.Title,
 			Content:          item.Description,
+			Description:      item.Description,
 			Link:             item.Link,
 			Published:        published,
 			Category:         category,
@@ -619,12 +493,20 @@ func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News,
 			SourceLang:       sourceLang,
 			SourceCategories: sourceCategories,
 			// Извлекаем изображение из RSS или из ссылки
-			ImageURL: extractImageURL(item),
+			ImageURL: extractImageURL(ctx, item),
 			ImageAlt: item.Title, // Используем заголовок как альтернативный текст
+			Scoring:  &scoring,
+
+			Fingerprint: fingerprint,
 		})
+	}
 
-		seenTitles = append(seenTitles, item.Title)
+	// Похожие статьи разных изданий объединяем в одну историю
+	clustered := clusterStories(candidates, opts.MaxFingerprintDistance, opts.Report)
+	if merged := len(candidates) - len(clustered); merged > 0 {
+		log.Printf("🧩 %d статей объединены в истории с несколькими источниками", merged)
 	}
+	candidates = clustered
 
 	// Сортировка: скор, затем новизна
 	sort.Slice(candidates, func(i, j int) bool {
@@ -638,22 +520,29 @@ func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News,
 		return nil, nil
 	}
 
-	// Применяем разнообразие: берём больше пула, чем финальный лимит, чтобы улучшить покрытие
-	pool := opts.Limit * 4
-	if pool > len(candidates) {
-		pool = len(candidates)
+	// Применяем разнообразие: каждый канал выбирает свой топ среди того, что он принимает,
+	// чтобы общие квоты по источникам/категориям не вытесняли узкие каналы
+	targets := opts.Targets
+	if len(targets) == 0 {
+		targets = []Target{{Limit: opts.Limit}}
 	}
-	diverseCandidates := selectDiverse(candidates[:pool], opts.Limit, opts.PerSource, opts.PerCategory)
-
-	newsLimit := opts.Limit
-	if len(diverseCandidates) < newsLimit {
-		newsLimit = len(diverseCandidates)
+	diverseCandidates := selectForTargets(candidates, targets, opts.PerSource, opts.PerCategory, opts.MaxFingerprintDistance)
+	chosen := make(map[string]bool, len(diverseCandidates))
+	for _, n := range diverseCandidates {
+		chosen[n.Link] = true
 	}
-
+	newsLimit := len(diverseCandidates)
 	urls := make([]string, newsLimit)
-	for i := 0; i < newsLimit; i++ {
+	for i := range diverseCandidates {
 		urls[i] = diverseCandidates[i].Link
 	}
+	if opts.Report != nil {
+		for _, c := range candidates {
+			if !chosen[c.Link] {
+				opts.Report.Reject(c.Link, "not selected: below the top items of every channel that accepts it or over the per-source/per-category cap")
+			}
+		}
+	}
 
 	// defaults for scraping limits
 	maxArticles := opts.ScrapeMaxArticles
@@ -666,10 +555,12 @@ func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News,
 	}
 
 	log.Printf("Извлекаем полный контент %d статей...", newsLimit)
-	fullArticles := scraper.ExtractArticlesInBackgroundWithLimits(urls, maxArticles, concurrency)
+	fullArticles := scraper.ExtractArticlesInBackgroundWithLimits(ctx, urls, maxArticles, concurrency)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	res := make([]News, 0, newsLimit)
-	geminiRequests := 0
+	selected := make([]News, newsLimit)
 	for i := 0; i < newsLimit; i++ {
 		n := diverseCandidates[i]
 		log.Printf("Getting full content of article %d/%d: %s", i+1, newsLimit, n.Link)
@@ -680,6 +571,29 @@ func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News,
 		} else {
 			log.Printf("⚠️ Using short description for: %s", n.Title)
 		}
+		selected[i] = n
+		opts.Report.markSelected(n.Link)
+		if n.Scoring != nil {
+			log.Printf("📊 %s: %s", n.Title, n.Scoring.Summary())
+		}
+	}
+
+	geminiRequests := 0
+	done := make([]bool, newsLimit)
+	if batcher, ok := newsProvider.(translate.BatchNewsProvider); ok && opts.BatchSize > 1 {
+		geminiRequests = processBatches(ctx, batcher, selected, done, opts.BatchSize, opts.MaxGeminiRequests)
+	}
+
+	res := make([]News, 0, newsLimit)
+	for i := range selected {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n := selected[i]
+		if done[i] {
+			res = append(res, n)
+			continue
+		}
 
 		// Определяем исходный язык
 		sourceLang := "da" // По умолчанию датский
@@ -687,70 +601,150 @@ func FilterAndTranslateWithOptions(items []*rss.FeedItem, opts Options) ([]News,
 			sourceLang = n.SourceLang
 		}
 
-		// Проверяем лимиты Gemini
+		// Проверяем лимиты основного AI-провайдера
+		var failed error
 		if opts.MaxGeminiRequests > 0 && geminiRequests >= opts.MaxGeminiRequests {
-			log.Printf("⚠️ Gemini requests limit exceeded, using fallback AI services")
-
-			// Краткая суть на исходном языке (для хранения)
-			n.Summary = fallbackSummary(n.Content)
-
-			// Используем бесплатные AI для саммари сразу на целевых языках
-			if daSum, err := translate.SummarizeText(n.Content, "da"); err == nil && strings.TrimSpace(daSum) != "" {
-				n.SummaryDanish = daSum
-			} else {
-				n.SummaryDanish = fallbackSummary(n.Content)
-			}
-			if ukSum, err := translate.SummarizeText(n.Content, "uk"); err == nil && strings.TrimSpace(ukSum) != "" {
-				n.SummaryUkrainian = ukSum
-			} else {
-				n.SummaryUkrainian = fallbackSummary(n.Content)
-			}
-
-			// Украинский заголовок
-			if ukTitle, err := translate.TranslateText(n.Title, sourceLang, "uk"); err == nil && strings.TrimSpace(ukTitle) != "" {
-				n.TitleUkrainian = ukTitle
-			}
-
+			log.Printf("⚠️ %s requests limit exceeded, using fallback AI services", newsProvider.Name())
+			failed = applyFallbackSummaries(ctx, &n, fallback, sourceLang)
 		} else {
-			aiResp, err := aiClient.TranslateAndSummarizeNews(n.Title, n.Content)
+			aiResp, err := newsProvider.TranslateAndSummarizeNews(ctx, n.Title, n.Content)
 			if err != nil {
-				log.Printf("⚠️ Gemini failed: %v, trying fallback AI services", err)
-
-				// Gemini не сработал — бесплатные AI саммари
-				n.Summary = fallbackSummary(n.Content)
-				if ukSum, err := translate.SummarizeText(n.Content, "uk"); err == nil && strings.TrimSpace(ukSum) != "" {
-					n.SummaryUkrainian = ukSum
-				} else {
-					n.SummaryUkrainian = fallbackSummary(n.Content)
-				}
-				if daSum, err := translate.SummarizeText(n.Content, "da"); err == nil && strings.TrimSpace(daSum) != "" {
-					n.SummaryDanish = daSum
-				} else {
-					n.SummaryDanish = fallbackSummary(n.Content)
-				}
-				if ukTitle, err := translate.TranslateText(n.Title, sourceLang, "uk"); err == nil && strings.TrimSpace(ukTitle) != "" {
-					n.TitleUkrainian = ukTitle
-				}
+				log.Printf("⚠️ %s failed: %v, trying fallback AI services", newsProvider.Name(), err)
+				failed = applyFallbackSummaries(ctx, &n, fallback, sourceLang)
 			} else {
-				// Gemini успешно
-				n.Summary = aiResp.Summary
-				n.SummaryDanish = aiResp.Danish
-				n.SummaryUkrainian = aiResp.Ukrainian
-				if ukTitle, err := translate.TranslateText(n.Title, sourceLang, "uk"); err == nil && strings.TrimSpace(ukTitle) != "" {
-					n.TitleUkrainian = ukTitle
-				}
-				log.Printf("✅ Gemini translation successful")
+				applyNewsTranslation(ctx, &n, aiResp)
+				log.Printf("✅ %s translation successful", newsProvider.Name())
 			}
 			geminiRequests++
 		}
+		if failed != nil {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			log.Printf("❌ Translation failed for %s: %v, skipping", n.Title, failed)
+			opts.Report.Reject(n.Link, fmt.Sprintf("translation failed: %v", failed))
+			continue
+		}
 		res = append(res, n)
-		time.Sleep(1 * time.Second) // Уменьшаем задержку для лучшей производительности
+		if err := sleepCtx(ctx, 1*time.Second); err != nil { // Уменьшаем задержку для лучшей производительности
+			return nil, err
+		}
 	}
 
 	log.Printf("Обработано %d новостей с саммаризацией", len(res))
+
+	// Only items that made it through translation are posted; their images are checked last
+	if opts.ImageRules != nil {
+		validateImages(ctx, res, *opts.ImageRules, concurrency, opts.KeepImages)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
 	return res, nil
 }
 
+// processBatches summarizes selected items in chunks of batchSize, one AI request per chunk.
+// Items answered by the provider are marked done; the rest are left for the one-by-one path.
+// Returns the number of API requests spent (cached batches are free).
+func processBatches(ctx context.Context, batcher translate.BatchNewsProvider, items []News, done []bool, batchSize, maxRequests int) int {
+	requests := 0
+	for start := 0; start < len(items); start += batchSize {
+		if (maxRequests > 0 && requests >= maxRequests) || ctx.Err() != nil {
+			break
+		}
+		end := start + batchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		articles := make([]translate.NewsArticle, 0, end-start)
+		for i := start; i < end; i++ {
+			articles = append(articles, translate.NewsArticle{ID: strconv.Itoa(i), Title: items[i].Title, Content: items[i].Content})
+		}
+
+		results, called, err := batcher.TranslateAndSummarizeBatch(ctx, articles)
+		if err != nil {
+			log.Printf("⚠️ %s batch of %d failed: %v, processing one by one", batcher.Name(), len(articles), err)
+		}
+		for i := start; i < end; i++ {
+			if tr, ok := results[strconv.Itoa(i)]; ok && tr != nil {
+				applyNewsTranslation(ctx, &items[i], tr)
+				done[i] = true
+			}
+		}
+		// A batch answered from cache costs no request and needs no pause
+		if !called {
+			continue
+		}
+		requests++
+		if sleepCtx(ctx, 1*time.Second) != nil {
+			break
+		}
+	}
+	return requests
+}
+
+// sleepCtx pauses between AI requests; returns ctx's error if cancelled while waiting
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// applyNewsTranslation copies provider output into the news item.
+// The Ukrainian title is translated separately only when the provider didn't return one.
+func applyNewsTranslation(ctx context.Context, n *News, tr *translate.NewsTranslation) {
+	n.Summary = tr.Summary
+	n.SummaryDanish = tr.Danish
+	n.SummaryUkrainian = tr.Ukrainian
+	// Keyword scoring only knows "general" as a catch-all; trust the model's more specific guess
+	if tr.Category != "" && n.Category == "general" {
+		n.Category = tr.Category
+	}
+
+	if strings.TrimSpace(tr.UkrainianTitle) != "" {
+		n.TitleUkrainian = tr.UkrainianTitle
+		return
+	}
+	sourceLang := "da"
+	if n.SourceLang != "" {
+		sourceLang = n.SourceLang
+	}
+	if ukTitle, err := aiRegistry.Translate(ctx, n.Title, sourceLang, "uk"); err == nil && strings.TrimSpace(ukTitle) != "" {
+		n.TitleUkrainian = ukTitle
+	}
+}
+
+// applyFallbackSummaries fills summaries and Ukrainian title using per-language summarizers of the fallback chain.
+// Returns an error when no service produced a Ukrainian summary; the item is not worth posting then.
+func applyFallbackSummaries(ctx context.Context, n *News, fallback *translate.Registry, sourceLang string) error {
+	// Краткая суть на исходном языке (для хранения)
+	n.Summary = fallbackSummary(n.Content)
+
+	if daSum, err := fallback.Summarize(ctx, n.Content, "da"); err == nil && strings.TrimSpace(daSum) != "" {
+		n.SummaryDanish = daSum
+	} else {
+		n.SummaryDanish = fallbackSummary(n.Content)
+	}
+	ukSum, err := fallback.Summarize(ctx, n.Content, "uk")
+	if err != nil {
+		return fmt.Errorf("no Ukrainian summary: %v", err)
+	}
+	if strings.TrimSpace(ukSum) == "" {
+		return fmt.Errorf("no Ukrainian summary: empty answer")
+	}
+	n.SummaryUkrainian = ukSum
+
+	// Украинский заголовок
+	if ukTitle, err := fallback.Translate(ctx, n.Title, sourceLang, "uk"); err == nil && strings.TrimSpace(ukTitle) != "" {
+		n.TitleUkrainian = ukTitle
+	}
+	return nil
+}
+
 func fallbackSummary(content string) string {
 	c := strings.TrimSpace(content)
 	if c == "" {
@@ -804,43 +798,31 @@ func FormatNewsWithImage(n News, minSentencesPerLang, maxSentencesPerLang int) s
 	useSentences := maxSentencesPerLang
 
 	var b strings.Builder
-	b.WriteString("🇩🇰 <b>Danish News</b> 🇺🇦\n\n")
-
-	// Датский блок - заголовок на отдельной строке
-	daTitle := n.Title
-	daText := strings.TrimSpace(n.SummaryDanish)
-	if daText == "" {
-		daText = fallbackSummary(n.Content)
-	}
-	daText = condenseSummary(daText, useSentences)
-	if daTitle != "" {
-		b.WriteString("🇩🇰 <b>" + daTitle + "</b>\n")
-	}
-	if daText != "" {
-		b.WriteString(daText + "\n\n")
-	}
-
-	// Украинский блок - заголовок на отдельной строке
-	ukTitle := strings.TrimSpace(n.TitleUkrainian)
-	if ukTitle == "" {
-		ukTitle = n.Title
-	}
-	ukText := strings.TrimSpace(n.SummaryUkrainian)
-	if ukText == "" {
-		ukText = fallbackSummary(n.Content)
-	}
-	ukText = condenseSummary(ukText, useSentences)
-	if ukTitle != "" {
-		b.WriteString("🇺🇦 <b>" + ukTitle + "</b>\n")
-	}
-	if ukText != "" {
-		b.WriteString(ukText + "\n")
+	b.WriteString(n.Layout.Pick("🇩🇰 <b>Danish News</b> 🇺🇦", "🇩🇰 <b>Danske nyheder</b>", "🇺🇦 <b>Новини Данії</b>") + "\n\n")
+
+	// Языковые блоки (датский, затем украинский) - заголовок на отдельной строке
+	blocks := langBlocks(n)
+	for i, blk := range blocks {
+		text := condenseSummary(blk.summary, useSentences)
+		if blk.title != "" {
+			b.WriteString(blk.flag + " <b>" + blk.title + "</b>\n")
+		}
+		if text != "" {
+			if i < len(blocks)-1 {
+				b.WriteString(text + "\n\n")
+			} else {
+				b.WriteString(text + "\n")
+			}
+		}
 	}
 
 	// Ссылка в конце для превью
 	if strings.TrimSpace(n.Link) != "" {
 		b.WriteString("\n🔗 " + n.Link)
 	}
+	if also := formatAlsoReportedBy(n); also != "" {
+		b.WriteString("\n" + also)
+	}
 
 	return b.String()
 }
@@ -879,85 +861,23 @@ func FormatCaptionForPhoto(n News, maxLen int, sentencesPerLang int, minPerLang
 	if minPerLang <= 0 {
 		minPerLang = 120
 	}
-	// Prepare pieces
-	daTitle := strings.TrimSpace(n.Title)
-	ukTitle := strings.TrimSpace(n.TitleUkrainian)
-	if ukTitle == "" {
-		ukTitle = daTitle
-	}
-	daSum := strings.TrimSpace(n.SummaryDanish)
-	if daSum == "" {
-		daSum = fallbackSummary(n.Content)
-	}
-	ukSum := strings.TrimSpace(n.SummaryUkrainian)
-	if ukSum == "" {
-		ukSum = fallbackSummary(n.Content)
-	}
-	// Condense to N sentences for photo caption
-	daSum = condenseSummary(daSum, sentencesPerLang)
-	ukSum = condenseSummary(ukSum, sentencesPerLang)
-
-	// Static header and separators (shorter for photo caption)
-	header := "🇩🇰 Danish News 🇺🇦\n\n"
-	footer := ""
-
-	// Skeleton without summaries to measure base (rune-aware)
-	composeBase := func(daT, ukT string) string {
-		var b strings.Builder
-		b.WriteString(header)
-		b.WriteString("🇩🇰 <b>" + daT + "</b>\n")
-		b.WriteString("%DA%\n\n")
-		b.WriteString("🇺🇦 <b>" + ukT + "</b>\n")
-		b.WriteString("%UK%")
-		b.WriteString(footer)
-		return b.String()
-	}
-
-	capStr := composeBase(daTitle, ukTitle)
-	baseLen := utf8.RuneCountInString(strings.ReplaceAll(strings.ReplaceAll(capStr, "%DA%", ""), "%UK%", ""))
-	// If even titles + header/footer exceed limit, trim titles first
-	if baseLen >= maxLen-40 { // leave minimal budget for summaries
-		roomForTitles := maxLen - utf8.RuneCountInString(header) - utf8.RuneCountInString(footer) - 8 - 40
-		if roomForTitles < 20 {
-			roomForTitles = 20
-		}
-		each := roomForTitles / 2
-		daTitle = trimToWordBoundary(daTitle, each)
-		ukTitle = trimToWordBoundary(ukTitle, each)
-		capStr = composeBase(daTitle, ukTitle)
-		baseLen = utf8.RuneCountInString(strings.ReplaceAll(strings.ReplaceAll(capStr, "%DA%", ""), "%UK%", ""))
+	// Prepare pieces, condensed to N sentences for photo caption
+	blocks := langBlocks(n)
+	for i := range blocks {
+		blocks[i].summary = condenseSummary(blocks[i].summary, sentencesPerLang)
 	}
 
+	capStr, baseLen := composeCaption(blocks, captionHeader(n.Layout), formatAlsoReportedBy(n), maxLen)
 	available := maxLen - baseLen
 	if available < 40 {
 		available = 40
 	}
-	// Dynamic allocation: minimal floor for each, remainder proportional to lengths
-	minFloor := minPerLang
-	if minFloor > available/2 {
-		minFloor = available / 2
-	}
-	rem := available - 2*minFloor
-	if rem < 0 {
-		rem = 0
-	}
-	daLen := utf8.RuneCountInString(daSum)
-	ukLen := utf8.RuneCountInString(ukSum)
-	totalLen := daLen + ukLen
-	var daBudget, ukBudget int
-	if totalLen > 0 && rem > 0 {
-		daBudget = minFloor + rem*daLen/totalLen
-		ukBudget = minFloor + rem*ukLen/totalLen
-	} else {
-		daBudget = available / 2
-		ukBudget = available - daBudget
-	}
-
-	daSum = trimToWordBoundary(daSum, daBudget)
-	ukSum = trimToWordBoundary(ukSum, ukBudget)
+	budgets := captionBudgets(blocks, available, minPerLang)
 
-	caption := strings.Replace(capStr, "%DA%", daSum, 1)
-	caption = strings.Replace(caption, "%UK%", ukSum, 1)
+	caption := capStr
+	for i, blk := range blocks {
+		caption = strings.Replace(caption, placeholder(i), trimToWordBoundary(blk.summary, budgets[i]), 1)
+	}
 
 	// Final guard rune-aware
 	if utf8.RuneCountInString(caption) > maxLen {
@@ -970,6 +890,12 @@ func FormatCaptionForPhoto(n News, maxLen int, sentencesPerLang int, minPerLang
 // ShouldUsePhoto returns true if a photo caption of up to maxLen runes can allocate
 // enough space for both Danish and Ukrainian summaries (after condensing) without becoming too short.
 func ShouldUsePhoto(n News, maxLen int, sentencesPerLang int, minPerLang int, minTotal int) bool {
+	ok, _ := PhotoDecision(n, maxLen, sentencesPerLang, minPerLang, minTotal)
+	return ok
+}
+
+// PhotoDecision is ShouldUsePhoto with a human-readable reason for the choice (used by dry-run previews)
+func PhotoDecision(n News, maxLen int, sentencesPerLang int, minPerLang int, minTotal int) (bool, string) {
 	if maxLen <= 0 || maxLen > 1024 {
 		maxLen = 1024
 	}
@@ -982,87 +908,140 @@ func ShouldUsePhoto(n News, maxLen int, sentencesPerLang int, minPerLang int, mi
 	if minTotal <= 0 {
 		minTotal = 180
 	}
-	// Prepare pieces
-	daTitle := strings.TrimSpace(n.Title)
-	ukTitle := strings.TrimSpace(n.TitleUkrainian)
-	if ukTitle == "" {
-		ukTitle = daTitle
-	}
-	daSum := strings.TrimSpace(n.SummaryDanish)
-	if daSum == "" {
-		daSum = fallbackSummary(n.Content)
-	}
-	ukSum := strings.TrimSpace(n.SummaryUkrainian)
-	if ukSum == "" {
-		ukSum = fallbackSummary(n.Content)
+	if n.Image != nil && !n.Image.OK {
+		return false, "image rejected: " + n.Image.Reason
 	}
+	blocks := langBlocks(n)
 	// If summaries are empty, photo caption won’t carry content meaningfully
-	if daSum == "" || ukSum == "" {
-		return false
+	for _, blk := range blocks {
+		if blk.summary == "" {
+			return false, "no Danish or Ukrainian summary for the caption"
+		}
 	}
 	// Condense for estimation
-	daSum = condenseSummary(daSum, sentencesPerLang)
-	ukSum = condenseSummary(ukSum, sentencesPerLang)
-	if daSum == "" || ukSum == "" {
-		return false
+	total := 0
+	for i := range blocks {
+		blocks[i].summary = condenseSummary(blocks[i].summary, sentencesPerLang)
+		if blocks[i].summary == "" {
+			return false, "summaries are empty after condensing"
+		}
+		total += utf8.RuneCountInString(blocks[i].summary)
 	}
 	// Минимальная суммарная информативность
-	if utf8.RuneCountInString(daSum)+utf8.RuneCountInString(ukSum) < minTotal {
-		return false
+	if total < minTotal {
+		return false, fmt.Sprintf("summaries too short for a photo post (%d < %d runes)", total, minTotal)
+	}
+	_, baseLen := composeCaption(blocks, captionHeader(n.Layout), formatAlsoReportedBy(n), maxLen)
+	available := maxLen - baseLen
+	if available < 40 {
+		return false, fmt.Sprintf("titles leave only %d runes of the %d caption limit", available, maxLen)
+	}
+	// Require minimal budgets to ensure each has at least a meaningful chunk
+	budgets := captionBudgets(blocks, available, minPerLang)
+	parts := make([]string, len(blocks))
+	tooSmall := false
+	for i, blk := range blocks {
+		parts[i] = fmt.Sprintf("%s %d", blockLang(blk), budgets[i])
+		tooSmall = tooSmall || budgets[i] < minPerLang
+	}
+	if tooSmall {
+		return false, fmt.Sprintf("caption budget per language too small (%s < %d runes)", strings.Join(parts, ", "), minPerLang)
 	}
-	header := "🇩🇰 Danish News 🇺🇦\n\n"
-	composeBase := func(daT, ukT string) string {
+	return true, fmt.Sprintf("caption fits %d runes (%s)", maxLen, strings.Join(parts, ", "))
+}
+
+// captionHeader starts every photo caption, in the layout's language
+func captionHeader(layout Layout) string {
+	return layout.Pick("🇩🇰 Danish News 🇺🇦", "🇩🇰 Danske nyheder", "🇺🇦 Новини Данії") + "\n\n"
+}
+
+func placeholder(i int) string { return fmt.Sprintf("%%SUM%d%%", i) }
+
+func blockLang(blk langBlock) string {
+	if blk.flag == "🇺🇦" {
+		return "uk"
+	}
+	return "da"
+}
+
+// composeCaption lays out header, titles, summary placeholders and the also-reported footer;
+// titles are trimmed when they leave no room for summaries. Returns the skeleton and its length without placeholders.
+func composeCaption(blocks []langBlock, header, also string, maxLen int) (string, int) {
+	footer := ""
+	if also != "" {
+		footer = "\n\n" + also
+	}
+	compose := func() string {
 		var b strings.Builder
 		b.WriteString(header)
-		b.WriteString("🇩🇰 <b>" + daT + "</b>\n")
-		b.WriteString("%DA%\n\n")
-		b.WriteString("🇺🇦 <b>" + ukT + "</b>\n")
-		b.WriteString("%UK%")
+		for i, blk := range blocks {
+			if i > 0 {
+				b.WriteString("\n\n")
+			}
+			b.WriteString(blk.flag + " <b>" + blk.title + "</b>\n")
+			b.WriteString(placeholder(i))
+		}
+		b.WriteString(footer)
 		return b.String()
 	}
-	capStr := composeBase(daTitle, ukTitle)
-	baseLen := utf8.RuneCountInString(strings.ReplaceAll(strings.ReplaceAll(capStr, "%DA%", ""), "%UK%", ""))
-	// Trim titles if necessary
-	if baseLen >= maxLen-40 {
-		roomForTitles := maxLen - utf8.RuneCountInString(header) - 8 - 40
+	baseLen := func(capStr string) int {
+		for i := range blocks {
+			capStr = strings.Replace(capStr, placeholder(i), "", 1)
+		}
+		return utf8.RuneCountInString(capStr)
+	}
+
+	capStr := compose()
+	n := baseLen(capStr)
+	// If even titles + header/footer exceed limit, trim titles first
+	if n >= maxLen-40 { // leave minimal budget for summaries
+		roomForTitles := maxLen - utf8.RuneCountInString(header) - utf8.RuneCountInString(footer) - 8 - 40
 		if roomForTitles < 20 {
 			roomForTitles = 20
 		}
-		each := roomForTitles / 2
-		daTitle = trimToWordBoundary(daTitle, each)
-		ukTitle = trimToWordBoundary(ukTitle, each)
-		capStr = composeBase(daTitle, ukTitle)
-		baseLen = utf8.RuneCountInString(strings.ReplaceAll(strings.ReplaceAll(capStr, "%DA%", ""), "%UK%", ""))
-	}
-	available := maxLen - baseLen
-	if available < 40 {
-		return false
+		each := roomForTitles / len(blocks)
+		for i := range blocks {
+			blocks[i].title = trimToWordBoundary(blocks[i].title, each)
+		}
+		capStr = compose()
+		n = baseLen(capStr)
 	}
-	// Dynamic budgets
+	return capStr, n
+}
+
+// captionBudgets splits available runes between the summaries: a floor of minPerLang each,
+// the remainder proportional to their lengths
+func captionBudgets(blocks []langBlock, available, minPerLang int) []int {
+	k := len(blocks)
 	minFloor := minPerLang
-	if minFloor > available/2 {
-		minFloor = available / 2
+	if minFloor > available/k {
+		minFloor = available / k
 	}
-	rem := available - 2*minFloor
+	rem := available - k*minFloor
 	if rem < 0 {
 		rem = 0
 	}
-	daLen := utf8.RuneCountInString(daSum)
-	ukLen := utf8.RuneCountInString(ukSum)
-	totalLen := daLen + ukLen
-	var daBudget, ukBudget int
+	totalLen := 0
+	for _, blk := range blocks {
+		totalLen += utf8.RuneCountInString(blk.summary)
+	}
+	budgets := make([]int, k)
 	if totalLen > 0 && rem > 0 {
-		daBudget = minFloor + rem*daLen/totalLen
-		ukBudget = minFloor + rem*ukLen/totalLen
-	} else {
-		daBudget = available / 2
-		ukBudget = available - daBudget
+		for i, blk := range blocks {
+			budgets[i] = minFloor + rem*utf8.RuneCountInString(blk.summary)/totalLen
+		}
+		return budgets
 	}
-	// Require minimal budgets to ensure each has at least a meaningful chunk
-	if daBudget < minPerLang || ukBudget < minPerLang {
-		return false
+	left := available
+	for i := range budgets {
+		if i == k-1 {
+			budgets[i] = left
+			break
+		}
+		budgets[i] = available / k
+		left -= budgets[i]
 	}
-	return true
+	return budgets
 }
 
 // condenseSummary returns up to maxSentences sentences from s, trimmed and joined with proper punctuation.
@@ -1196,6 +1175,37 @@ func isSimilarTitle(a, b string) bool {
 	return score >= 0.55
 }
 
+// selectForTargets picks every target's diverse top Limit among the candidates it accepts and has not
+// got yet; caps apply per target. Returns the union in candidate order (candidates are sorted by score).
+func selectForTargets(candidates []News, targets []Target, perSource, perCategory, maxDistance int) []News {
+	chosen := make(map[string]bool)
+	for _, t := range targets {
+		var accepted []News
+		for _, c := range candidates {
+			if t.Allows != nil && !t.Allows(c.Category, c.Score) {
+				continue
+			}
+			if findSentDuplicate(t.Sent, c.Link, c.Title, c.Fingerprint, maxDistance) != nil {
+				continue
+			}
+			accepted = append(accepted, c)
+		}
+		// берём больше пула, чем лимит канала, чтобы улучшить покрытие
+		pool := min(t.Limit*4, len(accepted))
+		for _, n := range selectDiverse(accepted[:pool], t.Limit, perSource, perCategory) {
+			chosen[n.Link] = true
+		}
+	}
+
+	var out []News
+	for _, c := range candidates {
+		if chosen[c.Link] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
 // selectDiverse выбирает до limit элементов из отсортированных candidates с ограничениями по источникам и категориям
 // candidates ожидается отсортированным по score desc + recency
 func selectDiverse(candidates []News, limit int, perSource int, perCategory int) []News {
@@ -1256,7 +1266,7 @@ func selectDiverse(candidates []News, limit int, perSource int, perCategory int)
 }
 
 // extractImageURL извлекает URL изображения из RSS элемента или веб-страницы
-func extractImageURL(item *rss.FeedItem) string {
+func extractImageURL(ctx context.Context, item *rss.FeedItem) string {
 	// 1) Используем стандартные enclosures из RSS (gofeed поддерживает item.Enclosures)
 	if item.Enclosures != nil {
 		for _, e := range item.Enclosures {
@@ -1290,7 +1300,7 @@ func extractImageURL(item *rss.FeedItem) string {
 
 	// 4) Fallback: fetch og:image from page
 	if strings.TrimSpace(item.Link) != "" {
-		if og, err := scraper.ExtractImageURL(item.Link); err == nil && strings.TrimSpace(og) != "" {
+		if og, err := scraper.ExtractImageURL(ctx, item.Link); err == nil && strings.TrimSpace(og) != "" {
 			return og
 		}
 	}
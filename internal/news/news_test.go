@@ -0,0 +1,246 @@
+package news
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deusflow/News/internal/media"
+	"github.com/deusflow/News/internal/rss"
+	"github.com/deusflow/News/internal/translate"
+	"github.com/mmcdole/gofeed"
+)
+
+func TestMain(m *testing.M) {
+	if err := LoadRules("../../configs/scoring_rules.yaml"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestCalculateNewsScore_SourceRules(t *testing.T) {
+	item := func(src *rss.FeedSource) *rss.FeedItem {
+		return &rss.FeedItem{
+			Item:   &gofeed.Item{Title: "Ny startup i København satser på robot-teknologi"},
+			Source: src,
+		}
+	}
+
+	category, base := calculateNewsScore(item(nil))
+	if category != "tech" || base == 0 {
+		t.Fatalf("unexpected base scoring: %q %d", category, base)
+	}
+
+	tests := []struct {
+		name string
+		src  rss.FeedSource
+		want int
+	}{
+		{"unset priority is neutral", rss.FeedSource{}, base},
+		{"high priority boosts", rss.FeedSource{Priority: 100}, int(float64(base)*1.2 + 0.5)},
+		{"low priority demotes", rss.FeedSource{Priority: 30}, int(float64(base)*0.92 + 0.5)},
+		{"weight multiplies", rss.FeedSource{Weight: 0.5}, base / 2},
+		{"declared topic alias boosts", rss.FeedSource{Categories: []string{"technology"}}, int(float64(base)*1.1 + 0.5)},
+		{"allowed list gates", rss.FeedSource{AllowedCategories: []string{"denmark"}}, 0},
+		{"allowed list passes", rss.FeedSource{AllowedCategories: []string{"Tech"}}, base},
+		{"blocked list gates", rss.FeedSource{BlockedCategories: []string{"technology"}}, 0},
+	}
+	for _, tt := range tests {
+		src := tt.src
+		if _, got := calculateNewsScore(item(&src)); got != tt.want {
+			t.Errorf("%s: score %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScoreItem_Explains(t *testing.T) {
+	item := &rss.FeedItem{
+		Item:   &gofeed.Item{Title: "Ny startup i København satser på robot-teknologi"},
+		Source: &rss.FeedSource{Name: "DR", Priority: 100, Weight: 0.9, Categories: []string{"tech"}},
+	}
+	res := ScoreItem(item)
+	if res.Category != "tech" || res.Rejected != "" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	sum := 0
+	for _, st := range res.Steps {
+		sum += st.Delta
+	}
+	if sum != res.Score {
+		t.Errorf("steps add up to %d, score is %d: %s", sum, res.Score, res.Summary())
+	}
+	if len(res.Matched["tech"]) == 0 || len(res.Matched["denmark"]) == 0 {
+		t.Errorf("matched keywords missing: %v", res.Matched)
+	}
+
+	item.Item.Title = "Vejret i København: regn hele weekenden"
+	if res := ScoreItem(item); res.Score != 0 || !strings.Contains(res.Rejected, "excluded by exclude") {
+		t.Errorf("expected exclusion reason, got %q (score %d)", res.Rejected, res.Score)
+	}
+
+	item.Item.Title = "Putin taler om krigen"
+	if res := ScoreItem(item); !strings.Contains(res.Rejected, "without local context") {
+		t.Errorf("expected missing context reason, got %q", res.Rejected)
+	}
+}
+
+func TestFormatLayouts(t *testing.T) {
+	n := News{
+		Title:            "Skolerne får flere lærere",
+		TitleUkrainian:   "Школи отримають більше вчителів",
+		SummaryDanish:    "Regeringen afsætter penge til flere lærere i folkeskolen. Aftalen gælder fra august.",
+		SummaryUkrainian: "Уряд виділяє гроші на більше вчителів у школах. Угода діє з серпня.",
+		Link:             "https://example.dk/skole",
+		AlsoReportedBy:   []SourceRef{{Name: "TV2", Link: "https://tv2.dk/skole"}},
+	}
+	for _, c := range []struct {
+		layout   Layout
+		has, not string
+		label    string // header and also-reported text in the layout's language
+	}{
+		{LayoutBilingual, "🇺🇦 <b>Школи", "", "Også hos / Також"},
+		{LayoutUkrainian, "🇺🇦 <b>Школи", "🇩🇰 <b>Skolerne", "Новини Данії"},
+		{LayoutDanish, "🇩🇰 <b>Skolerne", "🇺🇦 <b>Школи", "📰 Også hos: "},
+	} {
+		n.Layout = c.layout
+		for _, text := range []string{FormatNewsWithImage(n, 2, 2), FormatCaptionForPhoto(n, 900, 2, 40)} {
+			if c.layout == LayoutUkrainian && (strings.Contains(text, "Også") || strings.Contains(text, "Danish")) ||
+				c.layout == LayoutDanish && (strings.Contains(text, "Також") || strings.Contains(text, "Новини")) {
+				t.Errorf("%s: label in the wrong language:\n%s", c.layout, text)
+			}
+			if !strings.Contains(text, c.has) || !strings.Contains(text, c.label) || (c.not != "" && strings.Contains(text, c.not)) {
+				t.Errorf("%s: unexpected text:\n%s", c.layout, text)
+			}
+		}
+	}
+}
+
+func TestPhotoDecision_RejectedImage(t *testing.T) {
+	n := News{
+		Title:            "Skolerne får flere lærere",
+		SummaryDanish:    strings.Repeat("Regeringen afsætter penge til flere lærere i folkeskolen. ", 3),
+		SummaryUkrainian: strings.Repeat("Уряд виділяє гроші на більше вчителів у школах. ", 3),
+		ImageURL:         "https://example.dk/og.jpg",
+	}
+	if ok, why := PhotoDecision(n, 900, 2, 40, 100); !ok {
+		t.Fatalf("expected photo mode, got %s", why)
+	}
+	n.Image = &media.Check{Reason: "too small (300x200, need 400x200)"}
+	if ok, why := PhotoDecision(n, 900, 2, 40, 100); ok || !strings.Contains(why, "too small") {
+		t.Errorf("expected rejection by image check, got %v %s", ok, why)
+	}
+}
+
+// cachedBatcher answers every batch from its cache without calling the API
+type cachedBatcher struct{ batches int }
+
+func (b *cachedBatcher) Name() string                       { return "cached" }
+func (b *cachedBatcher) Capabilities() translate.Capability { return translate.CapSummarize }
+func (b *cachedBatcher) Translate(ctx context.Context, text, from, to string) (string, error) {
+	return text, nil
+}
+func (b *cachedBatcher) Summarize(ctx context.Context, text, lang string) (string, error) {
+	return text, nil
+}
+func (b *cachedBatcher) TranslateAndSummarizeNews(ctx context.Context, title, content string) (*translate.NewsTranslation, error) {
+	return nil, errors.New("not used")
+}
+func (b *cachedBatcher) TranslateAndSummarizeBatch(ctx context.Context, articles []translate.NewsArticle) (map[string]*translate.NewsTranslation, bool, error) {
+	b.batches++
+	results := map[string]*translate.NewsTranslation{}
+	for _, a := range articles {
+		results[a.ID] = &translate.NewsTranslation{Summary: a.Title, UkrainianTitle: "Заголовок"}
+	}
+	return results, false, nil
+}
+
+func TestProcessBatches_CachedBatchesAreFree(t *testing.T) {
+	items := make([]News, 5)
+	done := make([]bool, len(items))
+	batcher := &cachedBatcher{}
+	start := time.Now()
+	if requests := processBatches(context.Background(), batcher, items, done, 2, 1); requests != 0 {
+		t.Errorf("cached batches counted as %d requests", requests)
+	}
+	if batcher.batches != 3 || !done[4] {
+		t.Errorf("request budget stopped cached batches: %d batches, done %v", batcher.batches, done)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("cached batches should not pause between requests")
+	}
+}
+
+func TestSelectForTargets_NarrowChannelGetsItsCategories(t *testing.T) {
+	var candidates []News
+	for i, cat := range []string{"denmark", "denmark", "economy", "economy", "tech", "family", "education"} {
+		candidates = append(candidates, News{
+			Title: fmt.Sprintf("Story %d", i), Link: fmt.Sprintf("https://dr.dk/%d", i),
+			Category: cat, Score: 100 - i*10, SourceName: fmt.Sprintf("src%d", i),
+		})
+	}
+	family := func(category string, score int) bool { return category == "family" || category == "education" }
+	main := Target{Limit: 3}
+	kids := Target{Limit: 2, Allows: family}
+
+	got := selectForTargets(candidates, []Target{main, kids}, 2, 2, 12)
+	links := map[string]bool{}
+	for _, n := range got {
+		links[n.Link] = true
+	}
+	if len(got) != 5 || !links["https://dr.dk/5"] || !links["https://dr.dk/6"] {
+		t.Errorf("family items missing from the union: %v", links)
+	}
+	if got[0].Score < got[len(got)-1].Score {
+		t.Errorf("union should keep score order")
+	}
+
+	// Items the channel already got leave room for the next ones
+	kids.Sent = []SentStory{{Title: "Story 5", Link: "https://dr.dk/5"}}
+	got = selectForTargets(candidates, []Target{kids}, 2, 2, 12)
+	if len(got) != 1 || got[0].Link != "https://dr.dk/6" {
+		t.Errorf("expected only the unsent family item, got %+v", got)
+	}
+}
+
+func TestValidateImages_KeepsUploadOnlyWhenAsked(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 450))
+	for y := 0; y < 450; y++ {
+		for x := 0; x < 800; x++ {
+			v := uint8((x/40 + y/40) * 37)
+			img.Set(x, y, color.RGBA{R: v, G: 255 - v, B: v / 2, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "photo.jpg", time.Time{}, bytes.NewReader(buf.Bytes()))
+	}))
+	defer srv.Close()
+
+	for _, keep := range []bool{false, true} {
+		items := []News{{Title: "a", ImageURL: srv.URL + "/photo.jpg"}, {Title: "no image"}}
+		validateImages(context.Background(), items, media.Rules{}, 2, keep)
+		check := items[0].Image
+		if check == nil || !check.OK || check.Hash == 0 {
+			t.Fatalf("keep=%v: image not validated: %+v", keep, check)
+		}
+		if (check.Image != nil) != keep {
+			t.Errorf("keep=%v: prepared upload kept = %v", keep, check.Image != nil)
+		}
+		if items[1].Image != nil {
+			t.Errorf("item without image got a verdict: %+v", items[1].Image)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package news
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Report collects the scoring decision for every item the pipeline saw (see Options.Report)
+type Report struct {
+	Generated time.Time     `json:"generated"`
+	Items     []ScoreResult `json:"items"`
+
+	index map[string]int // link -> position in Items
+}
+
+// NewReport creates an empty report
+func NewReport() *Report {
+	return &Report{Generated: time.Now(), index: map[string]int{}}
+}
+
+// add records res; a nil report ignores it
+func (r *Report) add(res ScoreResult) {
+	if r == nil {
+		return
+	}
+	if _, ok := r.index[res.Link]; !ok {
+		r.index[res.Link] = len(r.Items)
+	}
+	r.Items = append(r.Items, res)
+}
+
+// reject records a drop for an item seen without scoring (too old, duplicate)
+func (r *Report) reject(res ScoreResult, reason string) {
+	res.Rejected = reason
+	r.add(res)
+}
+
+// Reject marks an already reported item as dropped by a later stage (e.g. already sent)
+func (r *Report) Reject(link, reason string) {
+	if r == nil {
+		return
+	}
+	if i, ok := r.index[link]; ok {
+		r.Items[i].Selected = false
+		r.Items[i].Rejected = reason
+	}
+}
+
+func (r *Report) markSelected(link string) {
+	if r == nil {
+		return
+	}
+	if i, ok := r.index[link]; ok {
+		r.Items[i].Selected = true
+	}
+}
+
+// WriteJSON writes the report to path
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode score report: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write score report: %v", err)
+	}
+	return nil
+}
+
+// setScore replaces the reported score of an item (e.g. after clustering)
+func (r *Report) setScore(link string, res ScoreResult) {
+	if r == nil {
+		return
+	}
+	if i, ok := r.index[link]; ok {
+		r.Items[i].Score = res.Score
+		r.Items[i].Steps = res.Steps
+	}
+}
@@ -0,0 +1,439 @@
+package news
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rules is the declarative scoring configuration (configs/scoring_rules.yaml)
+type Rules struct {
+	KeywordSets    map[string]map[string][]string `yaml:"keyword_sets"` // set -> language ("all", "da", "en", "uk") -> keywords
+	Contexts       map[string][]string            `yaml:"contexts"`     // context -> keyword sets, present if any matches
+	Exclude        []string                       `yaml:"exclude"`      // keyword sets that drop an item
+	RequireContext []ContextRule                  `yaml:"require_context"`
+	Categories     []CategoryRule                 `yaml:"categories"` // first match wins
+}
+
+// ContextRule drops items matching Set unless Context is present
+type ContextRule struct {
+	Set     string `yaml:"set"`
+	Context string `yaml:"context"`
+}
+
+// Condition matches when any listed set or inline keyword is found and none of the Unless sets is
+type Condition struct {
+	Any      []string `yaml:"any"`
+	Keywords []string `yaml:"keywords"`
+	Unless   []string `yaml:"unless"`
+}
+
+// CategoryRule assigns a category and base score to items matching When
+type CategoryRule struct {
+	Name           string    `yaml:"name"`
+	When           Condition `yaml:"when"`
+	Context        string    `yaml:"context"`         // required context (optional)
+	WithoutContext string    `yaml:"without_context"` // "skip" (default) or "reject"
+	Base           int       `yaml:"base"`
+	Boosts         []Boost   `yaml:"boosts"`
+}
+
+// Boost adds Score when its condition matches (negative scores are penalties)
+type Boost struct {
+	Condition `yaml:",inline"`
+	Score     int `yaml:"score"`
+}
+
+// keywordMatcher matches one keyword with the rules of matchesKeyword, precompiled
+type keywordMatcher struct {
+	keyword string
+	word    *regexp.Regexp // short tokens match as whole words only
+}
+
+func newKeywordMatcher(k string) (keywordMatcher, bool) {
+	k = strings.ToLower(strings.TrimSpace(k))
+	if k == "" {
+		return keywordMatcher{}, false
+	}
+	m := keywordMatcher{keyword: k}
+	if !strings.Contains(k, " ") && len(k) <= 3 {
+		m.word = regexp.MustCompile(`\b` + regexp.QuoteMeta(k) + `\b`)
+	}
+	return m, true
+}
+
+// match expects lowercased text
+func (m keywordMatcher) match(text string) bool {
+	if m.word != nil {
+		return m.word.MatchString(text)
+	}
+	return strings.Contains(text, m.keyword)
+}
+
+func compileKeywords(keywords []string) []keywordMatcher {
+	out := make([]keywordMatcher, 0, len(keywords))
+	for _, k := range keywords {
+		if m, ok := newKeywordMatcher(k); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// ruleSet is a validated Rules with keyword matchers compiled
+type ruleSet struct {
+	rules    Rules
+	sets     map[string]map[string][]keywordMatcher // set -> language -> matchers
+	setNames []string
+	inline   map[*Condition][]keywordMatcher
+}
+
+// compileRules validates references between sections and compiles keyword matchers
+func compileRules(r Rules) (*ruleSet, error) {
+	rs := &ruleSet{
+		rules:  r,
+		sets:   make(map[string]map[string][]keywordMatcher, len(r.KeywordSets)),
+		inline: make(map[*Condition][]keywordMatcher),
+	}
+	for name, langs := range r.KeywordSets {
+		rs.sets[name] = make(map[string][]keywordMatcher, len(langs))
+		for lang, keywords := range langs {
+			switch lang {
+			case "all", "da", "en", "uk":
+			default:
+				return nil, fmt.Errorf("keyword set %q: unsupported language %q (expected all, da, en or uk)", name, lang)
+			}
+			rs.sets[name][lang] = compileKeywords(keywords)
+		}
+		rs.setNames = append(rs.setNames, name)
+	}
+	sort.Strings(rs.setNames)
+
+	checkSets := func(where string, names []string) error {
+		for _, n := range names {
+			if _, ok := rs.sets[n]; !ok {
+				return fmt.Errorf("%s: unknown keyword set %q", where, n)
+			}
+		}
+		return nil
+	}
+	checkContext := func(where, name string) error {
+		if _, ok := r.Contexts[name]; !ok {
+			return fmt.Errorf("%s: unknown context %q", where, name)
+		}
+		return nil
+	}
+	checkCondition := func(where string, c *Condition) error {
+		if len(c.Any) == 0 && len(c.Keywords) == 0 {
+			return fmt.Errorf("%s: condition needs any or keywords", where)
+		}
+		if err := checkSets(where, c.Any); err != nil {
+			return err
+		}
+		if err := checkSets(where, c.Unless); err != nil {
+			return err
+		}
+		rs.inline[c] = compileKeywords(c.Keywords)
+		return nil
+	}
+
+	for name, sets := range r.Contexts {
+		if err := checkSets("context "+name, sets); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkSets("exclude", r.Exclude); err != nil {
+		return nil, err
+	}
+	for _, cr := range r.RequireContext {
+		if err := checkSets("require_context", []string{cr.Set}); err != nil {
+			return nil, err
+		}
+		if err := checkContext("require_context", cr.Context); err != nil {
+			return nil, err
+		}
+	}
+	if len(r.Categories) == 0 {
+		return nil, fmt.Errorf("no categories defined")
+	}
+	for i := range rs.rules.Categories {
+		c := &rs.rules.Categories[i]
+		where := fmt.Sprintf("category #%d %q", i+1, c.Name)
+		if strings.TrimSpace(c.Name) == "" {
+			return nil, fmt.Errorf("%s: name is empty", where)
+		}
+		if err := checkCondition(where, &c.When); err != nil {
+			return nil, err
+		}
+		if c.Context != "" {
+			if err := checkContext(where, c.Context); err != nil {
+				return nil, err
+			}
+		}
+		switch c.WithoutContext {
+		case "", "skip", "reject":
+		default:
+			return nil, fmt.Errorf("%s: without_context must be skip or reject, got %q", where, c.WithoutContext)
+		}
+		for j := range c.Boosts {
+			if err := checkCondition(fmt.Sprintf("%s boost #%d", where, j+1), &c.Boosts[j].Condition); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rs, nil
+}
+
+// evaluation caches keyword set matches for one item
+type evaluation struct {
+	rs      *ruleSet
+	text    string // lowercased title + description
+	lang    string
+	matched map[string]bool
+}
+
+func (rs *ruleSet) evaluate(text, lang string) *evaluation {
+	return &evaluation{rs: rs, text: strings.ToLower(text), lang: lang, matched: map[string]bool{}}
+}
+
+// setKeywords returns matchers of set applicable to the item language (all languages if unknown)
+func (e *evaluation) setKeywords(set string) []keywordMatcher {
+	var out []keywordMatcher
+	for lang, ms := range e.rs.sets[set] {
+		if lang == "all" || e.lang == "" || lang == e.lang {
+			out = append(out, ms...)
+		}
+	}
+	return out
+}
+
+func (e *evaluation) has(set string) bool {
+	if v, ok := e.matched[set]; ok {
+		return v
+	}
+	found := false
+	for _, m := range e.setKeywords(set) {
+		if m.match(e.text) {
+			found = true
+			break
+		}
+	}
+	e.matched[set] = found
+	return found
+}
+
+func (e *evaluation) hasAny(sets []string) bool {
+	for _, s := range sets {
+		if e.has(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *evaluation) context(name string) bool {
+	return e.hasAny(e.rs.rules.Contexts[name])
+}
+
+func (e *evaluation) condition(c *Condition) bool {
+	found := e.hasAny(c.Any)
+	if !found {
+		for _, m := range e.rs.inline[c] {
+			if m.match(e.text) {
+				found = true
+				break
+			}
+		}
+	}
+	return found && !e.hasAny(c.Unless)
+}
+
+// firstMatch returns the first keyword of set found in the text
+func (e *evaluation) firstMatch(set string) string {
+	for _, m := range e.setKeywords(set) {
+		if m.match(e.text) {
+			return m.keyword
+		}
+	}
+	return ""
+}
+
+// describe names what satisfied a matching condition, e.g. "denmark: københavn"
+func (e *evaluation) describe(c *Condition) string {
+	for _, set := range c.Any {
+		if e.has(set) {
+			return set + ": " + e.firstMatch(set)
+		}
+	}
+	for _, m := range e.rs.inline[c] {
+		if m.match(e.text) {
+			return m.keyword
+		}
+	}
+	return ""
+}
+
+// score returns the first matching category and its score, or "", 0 if the item is not relevant
+func (rs *ruleSet) score(text, lang string) (string, int) {
+	var res ScoreResult
+	rs.explain(text, lang, &res)
+	return res.Category, res.Score
+}
+
+// explain evaluates the rules and records category, score, every applied step and
+// the rejection reason in res
+func (rs *ruleSet) explain(text, lang string, res *ScoreResult) {
+	e := rs.evaluate(text, lang)
+	for _, set := range rs.rules.Exclude {
+		if e.has(set) {
+			res.Rejected = fmt.Sprintf("excluded by %s: %s", set, e.firstMatch(set))
+			return
+		}
+	}
+	for _, cr := range rs.rules.RequireContext {
+		if e.has(cr.Set) && !e.context(cr.Context) {
+			res.Rejected = fmt.Sprintf("%s: %s without %s context", cr.Set, e.firstMatch(cr.Set), cr.Context)
+			return
+		}
+	}
+
+	for i := range rs.rules.Categories {
+		c := &rs.rules.Categories[i]
+		if !e.condition(&c.When) {
+			continue
+		}
+		if c.Context != "" && !e.context(c.Context) {
+			if c.WithoutContext == "reject" {
+				res.Rejected = fmt.Sprintf("category %s (%s) without %s context", c.Name, e.describe(&c.When), c.Context)
+				return
+			}
+			continue
+		}
+		score := c.Base
+		steps := []ScoreStep{{Rule: fmt.Sprintf("category %s (%s)", c.Name, e.describe(&c.When)), Delta: c.Base}}
+		for j := range c.Boosts {
+			b := &c.Boosts[j]
+			if !e.condition(&b.Condition) {
+				continue
+			}
+			kind := "boost"
+			if b.Score < 0 {
+				kind = "penalty"
+			}
+			steps = append(steps, ScoreStep{Rule: kind + " " + e.describe(&b.Condition), Delta: b.Score})
+			score += b.Score
+		}
+		res.Steps = steps
+		if score <= 0 {
+			res.Rejected = fmt.Sprintf("category %s scored %d", c.Name, score)
+			return
+		}
+		res.Category, res.Score = c.Name, score
+		return
+	}
+	res.Rejected = "no category matched"
+}
+
+// matchedKeywords returns matched keywords by set name (for dknews score)
+func (rs *ruleSet) matchedKeywords(text, lang string) map[string][]string {
+	e := rs.evaluate(text, lang)
+	var out map[string][]string
+	for _, name := range rs.setNames {
+		var found []string
+		for _, m := range e.setKeywords(name) {
+			if m.match(e.text) {
+				found = append(found, m.keyword)
+			}
+		}
+		if len(found) > 0 {
+			if out == nil {
+				out = make(map[string][]string)
+			}
+			out[name] = found
+		}
+	}
+	return out
+}
+
+var (
+	rulesMu      sync.RWMutex
+	activeRules  *ruleSet
+	rulesPath    string
+	rulesModTime time.Time
+)
+
+// ParseRules decodes and validates rules without activating them
+func ParseRules(data []byte) (*Rules, error) {
+	var r Rules
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid scoring rules: %v", err)
+	}
+	if _, err := compileRules(r); err != nil {
+		return nil, fmt.Errorf("invalid scoring rules: %v", err)
+	}
+	return &r, nil
+}
+
+// LoadRules reads scoring rules from path and makes them active
+func LoadRules(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read scoring rules: %v", err)
+	}
+	rs, err := readRules(path)
+	if err != nil {
+		return err
+	}
+
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	activeRules, rulesPath, rulesModTime = rs, path, info.ModTime()
+	return nil
+}
+
+// ReloadRulesIfChanged re-reads the rules file loaded by LoadRules if it was modified since.
+// On error the previous rules stay active.
+func ReloadRulesIfChanged() (bool, error) {
+	rulesMu.RLock()
+	path, modTime := rulesPath, rulesModTime
+	rulesMu.RUnlock()
+	if path == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read scoring rules: %v", err)
+	}
+	if info.ModTime().Equal(modTime) {
+		return false, nil
+	}
+	if err := LoadRules(path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func readRules(path string) (*ruleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scoring rules: %v", err)
+	}
+	r, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return compileRules(*r)
+}
+
+func currentRules() *ruleSet {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return activeRules
+}
@@ -0,0 +1,87 @@
+package news
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRules_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"unknown set", "categories: [{name: a, when: {any: [nope]}, base: 1}]", `unknown keyword set "nope"`},
+		{"unknown context", "keyword_sets: {x: {all: [x]}}\ncategories: [{name: a, when: {any: [x]}, context: local, base: 1}]", `unknown context "local"`},
+		{"bad language", "keyword_sets: {x: {de: [x]}}\ncategories: [{name: a, when: {any: [x]}, base: 1}]", `unsupported language "de"`},
+		{"empty condition", "categories: [{name: a, base: 1}]", "condition needs any or keywords"},
+		{"no categories", "keyword_sets: {x: {all: [x]}}", "no categories defined"},
+	}
+	for _, tt := range tests {
+		_, err := ParseRules([]byte(tt.yaml))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want error containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRules_LanguageAndReload(t *testing.T) {
+	defer func() {
+		if err := LoadRules("../../configs/scoring_rules.yaml"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	write := func(base int, mtime time.Time) {
+		rules := "keyword_sets: {cph: {all: [københavn], en: [copenhagen]}}\n" +
+			"categories: [{name: denmark, when: {any: [cph]}, base: " + strconv.Itoa(base) + "}]\n"
+		if err := os.WriteFile(path, []byte(rules), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(5, time.Now().Add(-time.Hour))
+	if err := LoadRules(path); err != nil {
+		t.Fatal(err)
+	}
+
+	rs := currentRules()
+	if c, s := rs.score("Copenhagen news", "en"); c != "denmark" || s != 5 {
+		t.Errorf("en keyword on en feed: %q %d", c, s)
+	}
+	if _, s := rs.score("Copenhagen news", "da"); s != 0 {
+		t.Errorf("en keyword must not match da feed, got %d", s)
+	}
+
+	if reloaded, err := ReloadRulesIfChanged(); err != nil || reloaded {
+		t.Fatalf("unchanged file reloaded: %v %v", reloaded, err)
+	}
+	write(7, time.Now())
+	if reloaded, err := ReloadRulesIfChanged(); err != nil || !reloaded {
+		t.Fatalf("changed file not reloaded: %v %v", reloaded, err)
+	}
+	if _, s := currentRules().score("København", "da"); s != 7 {
+		t.Errorf("reloaded rules not active, score %d", s)
+	}
+
+	// A broken edit keeps the previous rules
+	if err := os.WriteFile(path, []byte("categories: ["), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, time.Now().Add(time.Hour), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReloadRulesIfChanged(); err == nil {
+		t.Fatal("expected error for invalid rules")
+	}
+	if _, s := currentRules().score("København", "da"); s != 7 {
+		t.Errorf("previous rules lost after failed reload, score %d", s)
+	}
+}
@@ -0,0 +1,235 @@
+package news
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/deusflow/News/internal/rss"
+)
+
+// Update is a newer article about a story that was already posted, to be sent as a reply
+type Update struct {
+	News     News      // the new article
+	Original SentStory // the first post of the story (reply target)
+	Novelty  float64   // share of the article's words not in anything posted about the story
+	Summary  string    // short Ukrainian summary of what changed
+}
+
+// UpdateOptions controls DetectUpdates
+type UpdateOptions struct {
+	MinNovelty             float64 // 0 = default 0.4
+	MinNewWords            int     // 0 = default 5
+	MaxUpdates             int     // 0 = default 2
+	MaxFingerprintDistance int     // 0 = default 12
+	MaxSummaries           int     // AI change summaries this call may request (run budget); 0 = no limit
+	SkipSummaries          bool    // detect only, leave Summary empty (dry run)
+}
+
+// DetectUpdates finds articles that repeat an already posted story but add enough new facts,
+// and summarizes what changed with the AI provider chain. Only stories posted with a known
+// Telegram message_id can get updates, and only from the posted article's own link: another
+// outlet's later rewrite of the story is new wording, not new facts.
+func DetectUpdates(ctx context.Context, items []*rss.FeedItem, sent []SentStory, opts UpdateOptions) ([]Update, error) {
+	if opts.MinNovelty <= 0 {
+		opts.MinNovelty = 0.4
+	}
+	if opts.MinNewWords <= 0 {
+		opts.MinNewWords = 5
+	}
+	if opts.MaxUpdates <= 0 {
+		opts.MaxUpdates = 2
+	}
+	if opts.MaxFingerprintDistance <= 0 {
+		opts.MaxFingerprintDistance = 12
+	}
+
+	best := map[int64]*Update{} // by original message_id
+	for _, item := range items {
+		text := item.Title + " " + item.Description
+		matches := sentMatches(sent, item.Link, item.Title, Fingerprint(item.Title, item.Description), opts.MaxFingerprintDistance)
+		original := firstPosted(matches)
+		if original == nil || !hasLink(matches, item.Link) {
+			continue
+		}
+		// Only articles published after the story was posted can carry news about it
+		if item.PublishedParsed == nil || !item.PublishedParsed.After(original.SentAt) {
+			continue
+		}
+
+		var known []string
+		for _, m := range matches {
+			known = append(known, m.Title+" "+m.Content)
+		}
+		novelty, newWords := wordNovelty(strings.Join(known, " "), text)
+		if novelty < opts.MinNovelty || newWords < opts.MinNewWords {
+			continue
+		}
+		if cur, ok := best[original.MessageID]; ok && cur.Novelty >= novelty {
+			continue
+		}
+
+		n := News{
+			Title:       item.Title,
+			Content:     item.Description,
+			Description: item.Description,
+			Link:        item.Link,
+			Published:   *item.PublishedParsed,
+			Fingerprint: Fingerprint(item.Title, item.Description),
+		}
+		if item.Source != nil {
+			n.SourceName, n.SourceLang = item.Source.Name, item.Source.Lang
+		}
+		best[original.MessageID] = &Update{News: n, Original: *original, Novelty: novelty}
+	}
+
+	updates := make([]Update, 0, len(best))
+	for _, u := range best {
+		updates = append(updates, *u)
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Novelty > updates[j].Novelty })
+	if len(updates) > opts.MaxUpdates {
+		updates = updates[:opts.MaxUpdates]
+	}
+
+	if opts.SkipSummaries {
+		return updates, nil
+	}
+
+	// A failed summary (AI quota, timeout) only drops that update, not the rest of the channel
+	summarized := updates[:0]
+	for i := range updates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if opts.MaxSummaries > 0 && i >= opts.MaxSummaries {
+			log.Printf("⚠️ AI budget for update summaries used up, skipping %d update(s)", len(updates)-i)
+			break
+		}
+		u := updates[i]
+		var known []string
+		for _, m := range sentMatches(sent, u.News.Link, u.News.Title, u.News.Fingerprint, opts.MaxFingerprintDistance) {
+			known = append(known, m.Title+" "+m.Content)
+		}
+		summary, err := summarizeChanges(ctx, strings.Join(known, " "), u.News.Title+". "+u.News.Description, u.News.SourceLang)
+		if err != nil {
+			log.Printf("⚠️ Failed to summarize update for %q: %v", u.Original.Title, err)
+			continue
+		}
+		u.Summary = summary
+		log.Printf("🔄 Update for %q: %s (%.0f%% new)", u.Original.Title, u.News.Title, u.Novelty*100)
+		summarized = append(summarized, u)
+	}
+	return summarized, nil
+}
+
+// sentMatches returns every sent story the article belongs to
+func sentMatches(sent []SentStory, link, title string, fp uint64, maxDistance int) []*SentStory {
+	var out []*SentStory
+	for i := range sent {
+		if findSentDuplicate(sent[i:i+1], link, title, fp, maxDistance) != nil {
+			out = append(out, &sent[i])
+		}
+	}
+	return out
+}
+
+// hasLink reports whether one of stories was posted from link
+func hasLink(stories []*SentStory, link string) bool {
+	for _, s := range stories {
+		if s.Link != "" && normalizeURL(s.Link) == normalizeURL(link) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstPosted returns the earliest story with a known Telegram message, or nil
+func firstPosted(stories []*SentStory) *SentStory {
+	var first *SentStory
+	for _, s := range stories {
+		if s.MessageID != 0 && (first == nil || s.SentAt.Before(first.SentAt)) {
+			first = s
+		}
+	}
+	return first
+}
+
+// wordNovelty returns the share and number of distinct (stemmed) words of text missing from known
+func wordNovelty(known, text string) (float64, int) {
+	seen := map[string]bool{}
+	for _, w := range fingerprintWords(known) {
+		seen[w] = true
+	}
+	words := map[string]bool{}
+	for _, w := range fingerprintWords(text) {
+		words[w] = true
+	}
+	if len(words) == 0 {
+		return 0, 0
+	}
+	fresh := 0
+	for w := range words {
+		if !seen[w] {
+			fresh++
+		}
+	}
+	return float64(fresh) / float64(len(words)), fresh
+}
+
+// newSentences keeps sentences of text that are mostly not covered by known
+func newSentences(known, text string) string {
+	var out []string
+	for _, s := range strings.FieldsFunc(text, func(r rune) bool { return r == '.' || r == '!' || r == '?' || r == '\n' }) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if novelty, _ := wordNovelty(known, s); novelty >= 0.4 {
+			out = append(out, s+".")
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// summarizeChanges writes a short Ukrainian summary of what text adds to known;
+// without a summarizer the new sentences are translated instead
+func summarizeChanges(ctx context.Context, known, text, lang string) (string, error) {
+	if aiRegistry == nil {
+		return "", fmt.Errorf("AI registry not initialized; call news.SetAIRegistry")
+	}
+	changes := newSentences(known, text)
+	if changes == "" {
+		changes = text
+	}
+	summary, err := aiRegistry.Summarize(ctx, changes, "uk")
+	if err == nil && strings.TrimSpace(summary) != "" {
+		return summary, nil
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	log.Printf("⚠️ Update summary failed (%v), translating the new sentences", err)
+	if lang == "" {
+		lang = "da"
+	}
+	return aiRegistry.Translate(ctx, condenseSummary(changes, 2), lang, "uk")
+}
+
+// FormatUpdate renders the reply text for an update (Telegram HTML)
+func FormatUpdate(u Update) string {
+	var b strings.Builder
+	b.WriteString("🔄 <b>Оновлення</b>\n\n")
+	b.WriteString(html.EscapeString(strings.TrimSpace(u.Summary)) + "\n")
+	if strings.TrimSpace(u.News.Link) != "" {
+		source := u.News.SourceName
+		if source == "" {
+			source = "link"
+		}
+		b.WriteString("\n🔗 <a href=\"" + html.EscapeString(u.News.Link) + "\">" + html.EscapeString(source) + "</a>")
+	}
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package news
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deusflow/News/internal/rss"
+	"github.com/deusflow/News/internal/translate"
+	"github.com/mmcdole/gofeed"
+)
+
+type summarizer struct{ input string }
+
+func (s *summarizer) Name() string                       { return "fake" }
+func (s *summarizer) Capabilities() translate.Capability { return translate.CapSummarize }
+func (s *summarizer) Translate(ctx context.Context, text, from, to string) (string, error) {
+	return text, nil
+}
+func (s *summarizer) Summarize(ctx context.Context, text, lang string) (string, error) {
+	s.input = text
+	return "Загинули двоє людей.", nil
+}
+
+func TestDetectUpdates(t *testing.T) {
+	fake := &summarizer{}
+	SetAIRegistry(translate.NewRegistry(fake))
+	defer SetAIRegistry(nil)
+
+	sentAt := time.Now().Add(-3 * time.Hour)
+	title := "Storbrand i Aarhus: Beboere evakueret i nat"
+	description := "En stor brand i et boligkompleks i Aarhus har tvunget beboerne ud om natten."
+	sent := []SentStory{{Title: title, Link: "https://dr.dk/brand", MessageID: 42, SentAt: sentAt, Content: description, Fingerprint: Fingerprint(title, description)}}
+
+	later := time.Now().Add(-time.Hour)
+	item := func(desc string, published time.Time) *rss.FeedItem {
+		return &rss.FeedItem{
+			Item:   &gofeed.Item{Title: title, Link: "https://dr.dk/brand", Description: desc, PublishedParsed: &published},
+			Source: &rss.FeedSource{Name: "DR", Lang: "da"},
+		}
+	}
+	updated := description + " Politiet oplyser nu, at to personer er omkommet, og at branden formentlig var påsat."
+
+	updates, err := DetectUpdates(context.Background(), []*rss.FeedItem{
+		item(description, later),              // unchanged
+		item(updated, sentAt.Add(-time.Hour)), // older than the post
+		item(updated, later),
+	}, sent, UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 {
+		t.Fatalf("expected one update, got %d", len(updates))
+	}
+	u := updates[0]
+	if u.Original.MessageID != 42 || u.Summary == "" {
+		t.Errorf("unexpected update: %+v", u)
+	}
+	if strings.Contains(fake.input, "boligkompleks") || !strings.Contains(fake.input, "omkommet") {
+		t.Errorf("summarizer should only get the new sentences, got %q", fake.input)
+	}
+	if text := FormatUpdate(u); !strings.HasPrefix(text, "🔄 <b>Оновлення</b>") {
+		t.Errorf("unexpected reply text: %s", text)
+	}
+
+	// Another outlet's later article about the story is a rewrite, not an update
+	rewrite := item(updated, later)
+	rewrite.Link = "https://tv2.dk/brand"
+	updates, err = DetectUpdates(context.Background(), []*rss.FeedItem{rewrite}, sent, UpdateOptions{})
+	if err != nil || len(updates) != 0 {
+		t.Errorf("rewrite by another outlet detected as update: %v %v", updates, err)
+	}
+
+	// A dry run detects the update without asking the AI for a summary
+	fake.input = ""
+	updates, err = DetectUpdates(context.Background(), []*rss.FeedItem{item(updated, later)}, sent, UpdateOptions{SkipSummaries: true})
+	if err != nil || len(updates) != 1 || updates[0].Summary != "" || fake.input != "" {
+		t.Errorf("dry run should skip the summary: %+v %v", updates, err)
+	}
+
+	// Once the update is stored as sent, the same article is not new anymore
+	sent = append(sent, SentStory{Title: title, Link: "https://dr.dk/brand", MessageID: 43, SentAt: time.Now(), Content: updated})
+	updates, err = DetectUpdates(context.Background(), []*rss.FeedItem{item(updated, later)}, sent, UpdateOptions{})
+	if err != nil || len(updates) != 0 {
+		t.Errorf("update detected again: %v %v", updates, err)
+	}
+}
+
+func TestDetectUpdates_SummaryFailure(t *testing.T) {
+	SetAIRegistry(nil) // every summary fails
+
+	sentAt := time.Now().Add(-3 * time.Hour)
+	title := "Storbrand i Aarhus: Beboere evakueret i nat"
+	description := "En stor brand i et boligkompleks i Aarhus har tvunget beboerne ud om natten."
+	sent := []SentStory{{Title: title, Link: "https://dr.dk/brand", MessageID: 42, SentAt: sentAt, Content: description, Fingerprint: Fingerprint(title, description)}}
+	later := time.Now().Add(-time.Hour)
+	item := &rss.FeedItem{
+		Item: &gofeed.Item{Title: title, Link: "https://dr.dk/brand", PublishedParsed: &later,
+			Description: description + " Politiet oplyser nu, at to personer er omkommet, og at branden formentlig var påsat."},
+		Source: &rss.FeedSource{Name: "DR", Lang: "da"},
+	}
+
+	// The failed update is skipped; it does not fail the whole channel
+	updates, err := DetectUpdates(context.Background(), []*rss.FeedItem{item}, sent, UpdateOptions{})
+	if err != nil || len(updates) != 0 {
+		t.Errorf("expected no updates and no error, got %v %v", updates, err)
+	}
+}
@@ -1,233 +1,227 @@
 package ratelimit
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 )
 
-// AIRateLimiter manages rate limiting for all AI services
+// ErrLimitExceeded is returned when a provider (or the total) quota is exhausted
+var ErrLimitExceeded = errors.New("AI rate limit exceeded")
+
+// Limits holds request caps per provider; 0 means unlimited
+type Limits struct {
+	Gemini  int `json:"gemini"`
+	Groq    int `json:"groq"`
+	Cohere  int `json:"cohere"`
+	Mistral int `json:"mistral"`
+	Total   int `json:"total"`
+}
+
+// Usage holds request counters per provider
+type Usage = Limits
+
+// State is the part of the limiter that survives restarts (daily counters and reset time)
+type State struct {
+	Daily       Usage     `json:"daily"`
+	ResetTime   time.Time `json:"reset_time"`
+	CacheHits   int       `json:"cache_hits"`
+	TokensSaved int       `json:"tokens_saved"`
+}
+
+// Store persists limiter state between runs (file, PostgreSQL)
+type Store interface {
+	LoadRateLimitState() (*State, error)
+	SaveRateLimitState(State) error
+}
+
+// AIRateLimiter manages rate limiting for all AI services.
+// Per-run limits apply to the current process, daily limits to persisted counters.
 type AIRateLimiter struct {
-	mu           sync.Mutex
-	geminiCount  int
-	groqCount    int
-	cohereCount  int
-	mistralCount int
-	totalCount   int
-	maxGemini    int
-	maxGroq      int
-	maxCohere    int
-	maxMistral   int
-	maxTotal     int
-	resetTime    time.Time
-	tokensSaved  int // Track how many tokens we saved via caching
-	cacheHits    int
-	cacheMisses  int
+	mu          sync.Mutex
+	run         Usage
+	daily       Usage
+	runLimits   Limits
+	dailyLimits Limits
+	resetTime   time.Time
+	tokensSaved int // Track how many tokens we saved via caching
+	cacheHits   int
+	cacheMisses int
+	store       Store
 }
 
-// NewAIRateLimiter creates a new rate limiter with configurable limits
+// NewAIRateLimiter creates a new rate limiter with configurable per-run limits
 func NewAIRateLimiter(maxGemini, maxGroq, maxCohere, maxMistral, maxTotal int) *AIRateLimiter {
 	return &AIRateLimiter{
-		maxGemini:  maxGemini,
-		maxGroq:    maxGroq,
-		maxCohere:  maxCohere,
-		maxMistral: maxMistral,
-		maxTotal:   maxTotal,
-		resetTime:  time.Now().Add(24 * time.Hour), // Reset daily
+		runLimits: Limits{
+			Gemini:  maxGemini,
+			Groq:    maxGroq,
+			Cohere:  maxCohere,
+			Mistral: maxMistral,
+			Total:   maxTotal,
+		},
+		resetTime: time.Now().Add(24 * time.Hour), // Reset daily
 	}
 }
 
-// CanUseGemini checks if we can make a Gemini request
-func (rl *AIRateLimiter) CanUseGemini() bool {
+// SetDailyLimits configures caps for the persisted daily counters
+func (rl *AIRateLimiter) SetDailyLimits(limits Limits) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
+	rl.dailyLimits = limits
+}
 
-	rl.checkReset()
-
-	if rl.maxGemini > 0 && rl.geminiCount >= rl.maxGemini {
-		log.Printf("⚠️ Gemini rate limit reached (%d/%d)", rl.geminiCount, rl.maxGemini)
-		return false
-	}
-
-	if rl.maxTotal > 0 && rl.totalCount >= rl.maxTotal {
-		log.Printf("⚠️ Total AI rate limit reached (%d/%d)", rl.totalCount, rl.maxTotal)
-		return false
+// Attach loads state from store and saves every change back to it
+func (rl *AIRateLimiter) Attach(store Store) error {
+	state, err := store.LoadRateLimitState()
+	if err != nil {
+		return fmt.Errorf("failed to load rate limiter state: %w", err)
 	}
 
-	return true
-}
-
-// CanUseGroq checks if we can make a Groq request
-func (rl *AIRateLimiter) CanUseGroq() bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
-
-	rl.checkReset()
-
-	if rl.maxGroq > 0 && rl.groqCount >= rl.maxGroq {
-		log.Printf("⚠️ Groq rate limit reached (%d/%d)", rl.groqCount, rl.maxGroq)
-		return false
+	rl.store = store
+	if state != nil && !state.ResetTime.IsZero() {
+		rl.daily = state.Daily
+		rl.resetTime = state.ResetTime
+		rl.cacheHits = state.CacheHits
+		rl.tokensSaved = state.TokensSaved
+		rl.checkReset()
+		log.Printf("📊 Restored AI usage: Gemini=%d Groq=%d Cohere=%d Mistral=%d Total=%d (reset at %s)",
+			rl.daily.Gemini, rl.daily.Groq, rl.daily.Cohere, rl.daily.Mistral, rl.daily.Total, rl.resetTime.Format(time.RFC3339))
 	}
+	return nil
+}
 
-	if rl.maxTotal > 0 && rl.totalCount >= rl.maxTotal {
-		log.Printf("⚠️ Total AI rate limit reached (%d/%d)", rl.totalCount, rl.maxTotal)
-		return false
+// counter returns pointers to the counters/limits of a provider; ok=false for untracked providers
+func counter(u *Usage, provider string) (*int, bool) {
+	switch strings.ToLower(provider) {
+	case "gemini":
+		return &u.Gemini, true
+	case "groq":
+		return &u.Groq, true
+	case "cohere":
+		return &u.Cohere, true
+	case "mistral":
+		return &u.Mistral, true
 	}
-
-	return true
+	return nil, false
 }
 
-// CanUseCohere checks if we can make a Cohere request
-func (rl *AIRateLimiter) CanUseCohere() bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
+// check returns an error if provider can't be used; caller must hold mu
+func (rl *AIRateLimiter) check(provider string) error {
 	rl.checkReset()
 
-	if rl.maxCohere > 0 && rl.cohereCount >= rl.maxCohere {
-		log.Printf("⚠️ Cohere rate limit reached (%d/%d)", rl.cohereCount, rl.maxCohere)
-		return false
+	if used, ok := counter(&rl.run, provider); ok {
+		limit, _ := counter(&rl.runLimits, provider)
+		if *limit > 0 && *used >= *limit {
+			return fmt.Errorf("%w: %s per-run limit reached (%d/%d)", ErrLimitExceeded, provider, *used, *limit)
+		}
+		dUsed, _ := counter(&rl.daily, provider)
+		dLimit, _ := counter(&rl.dailyLimits, provider)
+		if *dLimit > 0 && *dUsed >= *dLimit {
+			return fmt.Errorf("%w: %s daily limit reached (%d/%d)", ErrLimitExceeded, provider, *dUsed, *dLimit)
+		}
+	} else {
+		return nil // free/untracked providers (e.g. Google Translate) don't count towards totals
 	}
 
-	if rl.maxTotal > 0 && rl.totalCount >= rl.maxTotal {
-		log.Printf("⚠️ Total AI rate limit reached (%d/%d)", rl.totalCount, rl.maxTotal)
-		return false
+	if rl.runLimits.Total > 0 && rl.run.Total >= rl.runLimits.Total {
+		return fmt.Errorf("%w: total per-run limit reached (%d/%d)", ErrLimitExceeded, rl.run.Total, rl.runLimits.Total)
 	}
-
-	return true
+	if rl.dailyLimits.Total > 0 && rl.daily.Total >= rl.dailyLimits.Total {
+		return fmt.Errorf("%w: total daily limit reached (%d/%d)", ErrLimitExceeded, rl.daily.Total, rl.dailyLimits.Total)
+	}
+	return nil
 }
 
-// CanUseMistral checks if we can make a Mistral request
-func (rl *AIRateLimiter) CanUseMistral() bool {
+// CanUse checks if we can make a request to provider
+func (rl *AIRateLimiter) CanUse(provider string) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	rl.checkReset()
-
-	if rl.maxMistral > 0 && rl.mistralCount >= rl.maxMistral {
-		log.Printf("⚠️ Mistral rate limit reached (%d/%d)", rl.mistralCount, rl.maxMistral)
-		return false
-	}
-
-	if rl.maxTotal > 0 && rl.totalCount >= rl.maxTotal {
-		log.Printf("⚠️ Total AI rate limit reached (%d/%d)", rl.totalCount, rl.maxTotal)
+	if err := rl.check(provider); err != nil {
+		log.Printf("⚠️ %v", err)
 		return false
 	}
-
 	return true
 }
 
-// UseGemini increments Gemini counter
-func (rl *AIRateLimiter) UseGemini() error {
+// Use reserves one request for provider or returns an error wrapping ErrLimitExceeded
+func (rl *AIRateLimiter) Use(provider string) error {
 	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	rl.checkReset()
-
-	if rl.maxGemini > 0 && rl.geminiCount >= rl.maxGemini {
-		return fmt.Errorf("gemini rate limit exceeded")
+	if err := rl.check(provider); err != nil {
+		rl.mu.Unlock()
+		return err
 	}
-
-	if rl.maxTotal > 0 && rl.totalCount >= rl.maxTotal {
-		return fmt.Errorf("total AI rate limit exceeded")
+	runUsed, tracked := counter(&rl.run, provider)
+	if !tracked {
+		rl.mu.Unlock()
+		return nil
 	}
-
-	rl.geminiCount++
-	rl.totalCount++
+	dailyUsed, _ := counter(&rl.daily, provider)
+	*runUsed++
+	*dailyUsed++
+	rl.run.Total++
+	rl.daily.Total++
 	rl.cacheMisses++
 
-	log.Printf("📊 AI Usage: Gemini=%d/%d, Total=%d/%d", rl.geminiCount, rl.maxGemini, rl.totalCount, rl.maxTotal)
+	log.Printf("📊 AI Usage: %s=%d (today %d), Total=%d/%d (today %d)",
+		provider, *runUsed, *dailyUsed, rl.run.Total, rl.runLimits.Total, rl.daily.Total)
+	rl.mu.Unlock()
 
+	rl.persist()
 	return nil
 }
 
-// UseGroq increments Groq counter
-func (rl *AIRateLimiter) UseGroq() error {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	rl.checkReset()
+// CanUseGemini checks if we can make a Gemini request
+func (rl *AIRateLimiter) CanUseGemini() bool { return rl.CanUse("gemini") }
 
-	if rl.maxGroq > 0 && rl.groqCount >= rl.maxGroq {
-		return fmt.Errorf("groq rate limit exceeded")
-	}
+// CanUseGroq checks if we can make a Groq request
+func (rl *AIRateLimiter) CanUseGroq() bool { return rl.CanUse("groq") }
 
-	if rl.maxTotal > 0 && rl.totalCount >= rl.maxTotal {
-		return fmt.Errorf("total AI rate limit exceeded")
-	}
+// CanUseCohere checks if we can make a Cohere request
+func (rl *AIRateLimiter) CanUseCohere() bool { return rl.CanUse("cohere") }
 
-	rl.groqCount++
-	rl.totalCount++
-	rl.cacheMisses++
+// CanUseMistral checks if we can make a Mistral request
+func (rl *AIRateLimiter) CanUseMistral() bool { return rl.CanUse("mistral") }
 
-	log.Printf("📊 AI Usage: Groq=%d/%d, Total=%d/%d", rl.groqCount, rl.maxGroq, rl.totalCount, rl.maxTotal)
+// UseGemini increments Gemini counter
+func (rl *AIRateLimiter) UseGemini() error { return rl.Use("gemini") }
 
-	return nil
-}
+// UseGroq increments Groq counter
+func (rl *AIRateLimiter) UseGroq() error { return rl.Use("groq") }
 
 // UseCohere increments Cohere counter
-func (rl *AIRateLimiter) UseCohere() error {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	rl.checkReset()
-
-	if rl.maxCohere > 0 && rl.cohereCount >= rl.maxCohere {
-		return fmt.Errorf("cohere rate limit exceeded")
-	}
-
-	if rl.maxTotal > 0 && rl.totalCount >= rl.maxTotal {
-		return fmt.Errorf("total AI rate limit exceeded")
-	}
-
-	rl.cohereCount++
-	rl.totalCount++
-	rl.cacheMisses++
-
-	log.Printf("📊 AI Usage: Cohere=%d/%d, Total=%d/%d", rl.cohereCount, rl.maxCohere, rl.totalCount, rl.maxTotal)
-
-	return nil
-}
+func (rl *AIRateLimiter) UseCohere() error { return rl.Use("cohere") }
 
 // UseMistral increments Mistral counter
-func (rl *AIRateLimiter) UseMistral() error {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	rl.checkReset()
-
-	if rl.maxMistral > 0 && rl.mistralCount >= rl.maxMistral {
-		return fmt.Errorf("mistral rate limit exceeded")
-	}
-
-	if rl.maxTotal > 0 && rl.totalCount >= rl.maxTotal {
-		return fmt.Errorf("total AI rate limit exceeded")
-	}
-
-	rl.mistralCount++
-	rl.totalCount++
-	rl.cacheMisses++
-
-	log.Printf("📊 AI Usage: Mistral=%d/%d, Total=%d/%d", rl.mistralCount, rl.maxMistral, rl.totalCount, rl.maxTotal)
-
-	return nil
-}
+func (rl *AIRateLimiter) UseMistral() error { return rl.Use("mistral") }
 
 // RecordCacheHit records when we use cached translation (saves tokens!)
 func (rl *AIRateLimiter) RecordCacheHit(estimatedTokens int) {
 	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
 	rl.cacheHits++
 	rl.tokensSaved += estimatedTokens
 
 	log.Printf("💰 Cache HIT! Saved ~%d tokens (Total saved: %d, Hit rate: %.1f%%)",
-		estimatedTokens, rl.tokensSaved, rl.GetCacheHitRate())
+		estimatedTokens, rl.tokensSaved, rl.hitRate())
+	rl.mu.Unlock()
+
+	rl.persist()
 }
 
 // GetCacheHitRate returns cache hit rate percentage
 func (rl *AIRateLimiter) GetCacheHitRate() float64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.hitRate()
+}
+
+func (rl *AIRateLimiter) hitRate() float64 {
 	total := rl.cacheHits + rl.cacheMisses
 	if total == 0 {
 		return 0
@@ -241,21 +235,31 @@ func (rl *AIRateLimiter) GetStats() map[string]interface{} {
 	defer rl.mu.Unlock()
 
 	return map[string]interface{}{
-		"gemini_used":    rl.geminiCount,
-		"gemini_limit":   rl.maxGemini,
-		"groq_used":      rl.groqCount,
-		"groq_limit":     rl.maxGroq,
-		"cohere_used":    rl.cohereCount,
-		"cohere_limit":   rl.maxCohere,
-		"mistral_used":   rl.mistralCount,
-		"mistral_limit":  rl.maxMistral,
-		"total_used":     rl.totalCount,
-		"total_limit":    rl.maxTotal,
-		"cache_hits":     rl.cacheHits,
-		"cache_misses":   rl.cacheMisses,
-		"cache_hit_rate": rl.GetCacheHitRate(),
-		"tokens_saved":   rl.tokensSaved,
-		"reset_time":     rl.resetTime,
+		"gemini_used":         rl.run.Gemini,
+		"gemini_limit":        rl.runLimits.Gemini,
+		"gemini_daily_used":   rl.daily.Gemini,
+		"gemini_daily_limit":  rl.dailyLimits.Gemini,
+		"groq_used":           rl.run.Groq,
+		"groq_limit":          rl.runLimits.Groq,
+		"groq_daily_used":     rl.daily.Groq,
+		"groq_daily_limit":    rl.dailyLimits.Groq,
+		"cohere_used":         rl.run.Cohere,
+		"cohere_limit":        rl.runLimits.Cohere,
+		"cohere_daily_used":   rl.daily.Cohere,
+		"cohere_daily_limit":  rl.dailyLimits.Cohere,
+		"mistral_used":        rl.run.Mistral,
+		"mistral_limit":       rl.runLimits.Mistral,
+		"mistral_daily_used":  rl.daily.Mistral,
+		"mistral_daily_limit": rl.dailyLimits.Mistral,
+		"total_used":          rl.run.Total,
+		"total_limit":         rl.runLimits.Total,
+		"total_daily_used":    rl.daily.Total,
+		"total_daily_limit":   rl.dailyLimits.Total,
+		"cache_hits":          rl.cacheHits,
+		"cache_misses":        rl.cacheMisses,
+		"cache_hit_rate":      rl.hitRate(),
+		"tokens_saved":        rl.tokensSaved,
+		"reset_time":          rl.resetTime,
 	}
 }
 
@@ -263,28 +267,58 @@ func (rl *AIRateLimiter) GetStats() map[string]interface{} {
 func (rl *AIRateLimiter) PrintStats() {
 	stats := rl.GetStats()
 	log.Printf("📊 === AI Rate Limiter Statistics ===")
-	log.Printf("  Gemini:  %d/%d", stats["gemini_used"], stats["gemini_limit"])
-	log.Printf("  Groq:    %d/%d", stats["groq_used"], stats["groq_limit"])
-	log.Printf("  Cohere:  %d/%d", stats["cohere_used"], stats["cohere_limit"])
-	log.Printf("  Mistral: %d/%d", stats["mistral_used"], stats["mistral_limit"])
-	log.Printf("  Total:   %d/%d", stats["total_used"], stats["total_limit"])
+	log.Printf("  Gemini:  %d/%d (today %d/%d)", stats["gemini_used"], stats["gemini_limit"], stats["gemini_daily_used"], stats["gemini_daily_limit"])
+	log.Printf("  Groq:    %d/%d (today %d/%d)", stats["groq_used"], stats["groq_limit"], stats["groq_daily_used"], stats["groq_daily_limit"])
+	log.Printf("  Cohere:  %d/%d (today %d/%d)", stats["cohere_used"], stats["cohere_limit"], stats["cohere_daily_used"], stats["cohere_daily_limit"])
+	log.Printf("  Mistral: %d/%d (today %d/%d)", stats["mistral_used"], stats["mistral_limit"], stats["mistral_daily_used"], stats["mistral_daily_limit"])
+	log.Printf("  Total:   %d/%d (today %d/%d)", stats["total_used"], stats["total_limit"], stats["total_daily_used"], stats["total_daily_limit"])
 	log.Printf("  Cache:   %d hits, %d misses (%.1f%% hit rate)",
 		stats["cache_hits"], stats["cache_misses"], stats["cache_hit_rate"])
 	log.Printf("  Tokens saved: ~%d", stats["tokens_saved"])
 	log.Printf("=====================================")
 }
 
-// checkReset resets counters if reset time has passed
+// RunUsage returns the requests made in the current run
+func (rl *AIRateLimiter) RunUsage() Usage {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.run
+}
+
+// ResetRun clears per-run counters (used when one process serves several runs)
+func (rl *AIRateLimiter) ResetRun() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.run = Usage{}
+}
+
+// persist saves daily state to the attached store (if any)
+func (rl *AIRateLimiter) persist() {
+	rl.mu.Lock()
+	store := rl.store
+	state := State{
+		Daily:       rl.daily,
+		ResetTime:   rl.resetTime,
+		CacheHits:   rl.cacheHits,
+		TokensSaved: rl.tokensSaved,
+	}
+	rl.mu.Unlock()
+
+	if store == nil {
+		return
+	}
+	if err := store.SaveRateLimitState(state); err != nil {
+		log.Printf("⚠️ Failed to save AI rate limiter state: %v", err)
+	}
+}
+
+// checkReset resets daily counters if reset time has passed; caller must hold mu
 func (rl *AIRateLimiter) checkReset() {
 	if time.Now().After(rl.resetTime) {
-		log.Printf("🔄 Resetting AI rate limiter counters")
-		rl.PrintStats() // Print final stats before reset
-
-		rl.geminiCount = 0
-		rl.groqCount = 0
-		rl.cohereCount = 0
-		rl.mistralCount = 0
-		rl.totalCount = 0
+		log.Printf("🔄 Resetting AI rate limiter daily counters (today: Gemini=%d Groq=%d Cohere=%d Mistral=%d Total=%d, tokens saved ~%d)",
+			rl.daily.Gemini, rl.daily.Groq, rl.daily.Cohere, rl.daily.Mistral, rl.daily.Total, rl.tokensSaved)
+
+		rl.daily = Usage{}
 		rl.cacheHits = 0
 		rl.cacheMisses = 0
 		rl.tokensSaved = 0
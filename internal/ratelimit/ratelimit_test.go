@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestAIRateLimiter_DailyLimitSurvivesRestart(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "ai_usage.json"))
+
+	first := NewAIRateLimiter(0, 0, 0, 0, 0)
+	first.SetDailyLimits(Limits{Gemini: 2})
+	if err := first.Attach(store); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := first.Use("gemini"); err != nil {
+			t.Fatalf("use %d: %v", i, err)
+		}
+	}
+
+	// Next cron run: fresh process, same store
+	second := NewAIRateLimiter(0, 0, 0, 0, 0)
+	second.SetDailyLimits(Limits{Gemini: 2})
+	if err := second.Attach(store); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	if err := second.Use("gemini"); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("expected daily limit error, got %v", err)
+	}
+	if err := second.Use("groq"); err != nil {
+		t.Errorf("other providers must not be affected: %v", err)
+	}
+}
+
+func TestAIRateLimiter_PerRunAndTotalLimits(t *testing.T) {
+	rl := NewAIRateLimiter(1, 0, 0, 0, 2)
+
+	if err := rl.Use("gemini"); err != nil {
+		t.Fatalf("first gemini call: %v", err)
+	}
+	if err := rl.Use("gemini"); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected per-run gemini limit, got %v", err)
+	}
+	if err := rl.Use("groq"); err != nil {
+		t.Fatalf("groq call: %v", err)
+	}
+	if err := rl.Use("mistral"); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected total limit, got %v", err)
+	}
+	if err := rl.Use("google"); err != nil {
+		t.Errorf("untracked providers are free: %v", err)
+	}
+
+	rl.ResetRun()
+	if err := rl.Use("gemini"); err != nil {
+		t.Errorf("per-run counters should reset: %v", err)
+	}
+}
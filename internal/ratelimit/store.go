@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileStore keeps limiter state in a small JSON file (for runs without PostgreSQL)
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore creates a file-backed limiter state store
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// LoadRateLimitState reads state from file; missing or empty file means no state yet
+func (fs *FileStore) LoadRateLimitState() (*State, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	data, err := os.ReadFile(fs.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limit state file: %v", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rate limit state: %v", err)
+	}
+	return &state, nil
+}
+
+// SaveRateLimitState writes state to file
+func (fs *FileStore) SaveRateLimitState(state State) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal rate limit state: %v", err)
+	}
+	if err := os.WriteFile(fs.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write rate limit state file: %v", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -12,11 +13,30 @@ type RetryConfig struct {
 	Backoff     bool // Exponential backoff
 }
 
+// permanentError marks an error that must not be retried
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so WithRetry returns it right away instead of trying again
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// WithRetry calls fn up to MaxAttempts times; an error wrapped with Permanent stops at once and is returned unwrapped
 func WithRetry(ctx context.Context, config RetryConfig, fn func() error) error {
 	var lastErr error
 
 	for attempt := 1; attempt <= config.MaxAttempts; attempt++ {
 		if err := fn(); err != nil {
+			var perm *permanentError
+			if errors.As(err, &perm) {
+				return perm.err
+			}
 			lastErr = err
 
 			if attempt == config.MaxAttempts {
@@ -0,0 +1,29 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithRetry_Permanent(t *testing.T) {
+	quota := errors.New("quota")
+	calls := 0
+	err := WithRetry(context.Background(), RetryConfig{MaxAttempts: 3, Delay: time.Millisecond}, func() error {
+		calls++
+		return Permanent(quota)
+	})
+	if err != quota || calls != 1 {
+		t.Errorf("expected the unwrapped error after one call, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = WithRetry(context.Background(), RetryConfig{MaxAttempts: 3, Delay: time.Millisecond}, func() error {
+		calls++
+		return errors.New("timeout")
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("expected 3 calls, got %d (%v)", calls, err)
+	}
+}
@@ -0,0 +1,273 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/deusflow/News/internal/metrics"
+	"github.com/mmcdole/gofeed"
+)
+
+// requestTimeout bounds downloading and parsing a single feed
+var requestTimeout = 20 * time.Second
+
+// SetRequestTimeout changes the default per-feed timeout (d <= 0 keeps the current value)
+func SetRequestTimeout(d time.Duration) {
+	if d > 0 {
+		requestTimeout = d
+	}
+}
+
+// maxFeedSize protects against misconfigured URLs pointing at huge files
+const maxFeedSize = 10 << 20
+
+// FeedResult is the outcome of fetching one feed (shared with metrics so /metrics can expose it)
+type FeedResult = metrics.FeedResult
+
+// FeedState is the HTTP caching state and health of one feed remembered between runs.
+// Body is the last full response; it is re-parsed when the server answers 304 Not Modified.
+type FeedState struct {
+	ETag         string     `json:"etag,omitempty"`
+	LastModified string     `json:"last_modified,omitempty"`
+	Body         string     `json:"body,omitempty"`
+	FetchedAt    time.Time  `json:"fetched_at"`
+	Health       FeedHealth `json:"health"`
+}
+
+// StateStore persists FeedState keyed by feed URL (implemented by FileStateStore and storage.PostgresCache)
+type StateStore interface {
+	LoadFeedStates() (map[string]FeedState, error)
+	SaveFeedStates(map[string]FeedState) error
+}
+
+// FetchOptions controls FetchFeeds
+type FetchOptions struct {
+	Concurrency int           // parallel downloads (default 4)
+	Timeout     time.Duration // per feed (default SetRequestTimeout value)
+	States      StateStore    // conditional GET state and health; nil always downloads full feeds
+
+	DisableAfter    int           // soft-disable a feed after this many consecutive failures (0 = never)
+	ReprobeInterval time.Duration // how long a disabled feed is skipped before the next probe (default 6h)
+	StaleAfter      time.Duration // warn about feeds without new items for this long (0 = off)
+}
+
+// FetchFeeds downloads active feeds with a bounded worker pool and returns their items in
+// config order together with one result per active feed. Results are also published to metrics.
+// Cancelling ctx aborts downloads in flight and returns ctx's error.
+func FetchFeeds(ctx context.Context, sources []FeedSource, opts FetchOptions) ([]*FeedItem, []FeedResult, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = requestTimeout
+	}
+	if opts.ReprobeInterval <= 0 {
+		opts.ReprobeInterval = 6 * time.Hour
+	}
+	now := time.Now()
+
+	states := map[string]FeedState{}
+	if opts.States != nil {
+		loaded, err := opts.States.LoadFeedStates()
+		if err != nil {
+			log.Printf("⚠️ Failed to load feed state, fetching everything: %v", err)
+		} else if loaded != nil {
+			states = loaded
+		}
+	}
+
+	var active []int
+	for i, source := range sources {
+		if !source.Active {
+			log.Printf("Skipping inactive feed: %s", source.Name)
+			continue
+		}
+		active = append(active, i)
+	}
+
+	items := make([][]*FeedItem, len(active))
+	results := make([]FeedResult, len(active))
+	newStates := make([]*FeedState, len(active))
+
+	// Soft-disabled feeds are skipped until their next probe time
+	var pending []int
+	for j, i := range active {
+		source := sources[i]
+		if h := states[source.URL].Health; h.Disabled(now) {
+			results[j] = FeedResult{
+				Name:   source.Name,
+				URL:    source.URL,
+				Status: metrics.FeedDisabled,
+				Error:  fmt.Sprintf("disabled after %d failures, next probe at %s", h.ConsecutiveFailures, h.DisabledUntil.Format(time.RFC3339)),
+			}
+			continue
+		}
+		pending = append(pending, j)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	workers := opts.Concurrency
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			parser := gofeed.NewParser()
+			for j := range jobs {
+				source := &sources[active[j]]
+				prev, hasPrev := states[source.URL]
+				var prevState *FeedState
+				if hasPrev {
+					prevState = &prev
+				}
+				items[j], results[j], newStates[j] = fetchFeed(ctx, parser, source, prevState, opts.Timeout)
+			}
+		}()
+	}
+	for _, j := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var allItems []*FeedItem
+	okCount := 0
+	for _, j := range pending {
+		allItems = append(allItems, items[j]...)
+		source := sources[active[j]]
+		st := states[source.URL]
+		if ns := newStates[j]; ns != nil {
+			st.ETag, st.LastModified, st.Body, st.FetchedAt = ns.ETag, ns.LastModified, ns.Body, ns.FetchedAt
+		}
+
+		r := &results[j]
+		switch r.Status {
+		case metrics.FeedOK, metrics.FeedNotModified:
+			okCount++
+			st.Health.recordSuccess(items[j], now)
+		default:
+			st.Health.recordFailure(r.Error, r.Status == metrics.FeedParseError, now, opts.DisableAfter, opts.ReprobeInterval)
+			if st.Health.Disabled(now) {
+				log.Printf("🚫 Feed %s disabled after %d consecutive failures, next probe at %s", source.Name, st.Health.ConsecutiveFailures, st.Health.DisabledUntil.Format(time.RFC3339))
+			}
+		}
+		r.ConsecutiveFailures = st.Health.ConsecutiveFailures
+		r.LastSuccess = st.Health.LastSuccess
+		if st.Health.Stale(now, opts.StaleAfter) {
+			r.Stale = true
+			log.Printf("⚠️ Feed %s has no new items since %s, its URL may have moved: %s", source.Name, st.Health.NewestItem.Format("2006-01-02"), source.URL)
+		}
+		states[source.URL] = st
+	}
+	for j := range active {
+		if results[j].Status == metrics.FeedDisabled {
+			h := states[sources[active[j]].URL].Health
+			results[j].ConsecutiveFailures, results[j].LastSuccess = h.ConsecutiveFailures, h.LastSuccess
+		}
+	}
+
+	if opts.States != nil {
+		if err := opts.States.SaveFeedStates(states); err != nil {
+			log.Printf("⚠️ Failed to save feed state: %v", err)
+		}
+	}
+	metrics.Global.SetFeedResults(results)
+	log.Printf("Processed RSS feeds: %d/%d ok", okCount, len(active))
+	return allItems, results, nil
+}
+
+// fetchFeed downloads one feed, sending validators from prev so an unchanged feed answers 304.
+// The returned validators are nil when the stored ones should be kept; empty validators mean
+// the server no longer supports conditional GET.
+func fetchFeed(ctx context.Context, parser *gofeed.Parser, source *FeedSource, prev *FeedState, timeout time.Duration) ([]*FeedItem, FeedResult, *FeedState) {
+	started := time.Now()
+	result := FeedResult{Name: source.Name, URL: source.URL}
+	fail := func(err error) ([]*FeedItem, FeedResult, *FeedState) {
+		if result.Status != metrics.FeedParseError {
+			result.Status = metrics.FeedFailed
+		}
+		result.Error = err.Error()
+		result.Duration = time.Since(started)
+		log.Printf("Error parsing RSS %s (%s): %v", source.URL, source.Name, err)
+		return nil, result, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", source.URL, nil)
+	if err != nil {
+		return fail(err)
+	}
+	req.Header.Set("User-Agent", "Gofeed/1.0")
+	if prev != nil && prev.Body != "" {
+		if prev.ETag != "" {
+			req.Header.Set("If-None-Match", prev.ETag)
+		}
+		if prev.LastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.LastModified)
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+	result.HTTPStatus = resp.StatusCode
+
+	var body []byte
+	var state *FeedState
+	switch {
+	case resp.StatusCode == http.StatusNotModified && prev != nil && prev.Body != "":
+		result.Status = metrics.FeedNotModified
+		body = []byte(prev.Body)
+	case resp.StatusCode == http.StatusOK:
+		result.Status = metrics.FeedOK
+		body, err = io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+		if err != nil {
+			return fail(fmt.Errorf("error reading feed: %v", err))
+		}
+		state = &FeedState{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified"), FetchedAt: time.Now()}
+		if state.ETag != "" || state.LastModified != "" {
+			state.Body = string(body)
+		}
+	default:
+		return fail(fmt.Errorf("http status %d", resp.StatusCode))
+	}
+
+	feed, err := parser.Parse(bytes.NewReader(body))
+	if err != nil {
+		result.Status = metrics.FeedParseError
+		return fail(err)
+	}
+
+	items := make([]*FeedItem, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		items = append(items, &FeedItem{Item: item, Source: source})
+	}
+	result.Items = len(items)
+	result.Duration = time.Since(started)
+	if result.Status == metrics.FeedNotModified {
+		log.Printf("Loaded %d news from %s (%s, not modified)", len(items), source.Name, source.URL)
+	} else {
+		log.Printf("Loaded %d news from %s (%s)", len(items), source.Name, source.URL)
+	}
+	return items, result, state
+}
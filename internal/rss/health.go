@@ -0,0 +1,97 @@
+package rss
+
+import (
+	"sort"
+	"time"
+)
+
+// FeedHealth is the fetch history of one feed, kept in FeedState between runs
+type FeedHealth struct {
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	ParseErrors         int       `json:"parse_errors"`
+	Successes           int       `json:"successes"`
+	AvgItems            float64   `json:"avg_items"`
+	LastSuccess         time.Time `json:"last_success"`
+	LastFailure         time.Time `json:"last_failure"`
+	LastError           string    `json:"last_error,omitempty"`
+	NewestItem          time.Time `json:"newest_item"`    // newest publish date seen in the feed
+	DisabledUntil       time.Time `json:"disabled_until"` // soft-disabled until the next probe
+}
+
+// Disabled reports whether the feed is soft-disabled at now
+func (h FeedHealth) Disabled(now time.Time) bool {
+	return now.Before(h.DisabledUntil)
+}
+
+// Stale reports whether the feed had no new items for longer than after (0 disables the check).
+// Feeds whose items carry no dates are never stale.
+func (h FeedHealth) Stale(now time.Time, after time.Duration) bool {
+	return after > 0 && !h.NewestItem.IsZero() && now.Sub(h.NewestItem) > after
+}
+
+func (h *FeedHealth) recordSuccess(items []*FeedItem, now time.Time) {
+	h.ConsecutiveFailures = 0
+	h.DisabledUntil = time.Time{}
+	h.LastSuccess = now
+	h.AvgItems = (h.AvgItems*float64(h.Successes) + float64(len(items))) / float64(h.Successes+1)
+	h.Successes++
+
+	// Ignore dates from the future (broken time zones) so they don't hide a dead feed
+	limit := now.Add(24 * time.Hour)
+	for _, it := range items {
+		p := it.PublishedParsed
+		if p == nil {
+			p = it.UpdatedParsed
+		}
+		if p != nil && p.After(h.NewestItem) && p.Before(limit) {
+			h.NewestItem = *p
+		}
+	}
+}
+
+// recordFailure counts a failed fetch; after disableAfter failures in a row the feed is
+// skipped for reprobe, and every failed probe pushes the next one further by the same interval
+func (h *FeedHealth) recordFailure(errText string, parseError bool, now time.Time, disableAfter int, reprobe time.Duration) {
+	h.ConsecutiveFailures++
+	if parseError {
+		h.ParseErrors++
+	}
+	h.LastFailure = now
+	h.LastError = errText
+	if disableAfter > 0 && h.ConsecutiveFailures >= disableAfter {
+		h.DisabledUntil = now.Add(reprobe)
+	}
+}
+
+// HealthReport is one row of "dknews feeds health"
+type HealthReport struct {
+	Name   string     `json:"name"`
+	URL    string     `json:"url"`
+	Active bool       `json:"active"`
+	Health FeedHealth `json:"health"`
+	Status string     `json:"status"` // "ok", "failing", "disabled", "stale" or "unknown"
+}
+
+// BuildHealthReport combines the feeds config with stored state; the worst feeds come first
+func BuildHealthReport(sources []FeedSource, states map[string]FeedState, staleAfter time.Duration, now time.Time) []HealthReport {
+	rank := map[string]int{"disabled": 0, "failing": 1, "stale": 2, "unknown": 3, "ok": 4}
+	report := make([]HealthReport, 0, len(sources))
+	for _, s := range sources {
+		st, known := states[s.URL]
+		h := st.Health
+		r := HealthReport{Name: s.Name, URL: s.URL, Active: s.Active, Health: h, Status: "ok"}
+		switch {
+		case !known || (h.Successes == 0 && h.ConsecutiveFailures == 0):
+			r.Status = "unknown"
+		case h.Disabled(now):
+			r.Status = "disabled"
+		case h.ConsecutiveFailures > 0:
+			r.Status = "failing"
+		case h.Stale(now, staleAfter):
+			r.Status = "stale"
+		}
+		report = append(report, r)
+	}
+	sort.SliceStable(report, func(i, j int) bool { return rank[report[i].Status] < rank[report[j].Status] })
+	return report
+}
@@ -1,8 +1,13 @@
 package rss
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/mmcdole/gofeed"
 	"gopkg.in/yaml.v3"
@@ -13,9 +18,14 @@ type FeedSource struct {
 	URL        string   `yaml:"url"`
 	Name       string   `yaml:"name"`
 	Lang       string   `yaml:"lang"`
-	Priority   int      `yaml:"priority"`
+	Priority   int      `yaml:"priority"` // 0-100, weights item scores (0 = neutral)
 	Active     bool     `yaml:"active"`
-	Categories []string `yaml:"categories"`
+	Categories []string `yaml:"categories"` // topics the feed is known for; matching items get a boost
+
+	// Scoring overrides
+	Weight            float64  `yaml:"weight"`             // extra score multiplier (0 = 1.0)
+	AllowedCategories []string `yaml:"allowed_categories"` // if set, items in other categories are dropped
+	BlockedCategories []string `yaml:"blocked_categories"` // items in these categories are dropped
 }
 
 // FeedsConfig is YAML config structure for extended feeds format
@@ -49,37 +59,66 @@ func LoadFeeds(path string) ([]FeedSource, error) {
 	return cfg.Feeds, nil
 }
 
-// FetchAllFeeds downloads and parses all feeds, returns news list with source metadata
-func FetchAllFeeds(sources []FeedSource) ([]*FeedItem, error) {
-	parser := gofeed.NewParser()
-	var allItems []*FeedItem
-	successCount := 0
+// FetchAllFeeds downloads and parses all active feeds in parallel without conditional GET state.
+// Cancelling ctx aborts downloads in flight and returns ctx's error.
+func FetchAllFeeds(ctx context.Context, sources []FeedSource) ([]*FeedItem, error) {
+	items, _, err := FetchFeeds(ctx, sources, FetchOptions{})
+	return items, err
+}
 
-	for _, source := range sources {
-		if !source.Active {
-			log.Printf("Skipping inactive feed: %s", source.Name)
-			continue
+// ValidateFeeds checks the feeds list for configuration mistakes without network access
+func ValidateFeeds(sources []FeedSource) []error {
+	var problems []error
+	seen := make(map[string]string)
+	for i, s := range sources {
+		label := s.Name
+		if label == "" {
+			label = fmt.Sprintf("feed #%d", i+1)
+			problems = append(problems, fmt.Errorf("%s: name is empty", label))
 		}
 
-		feed, err := parser.ParseURL(source.URL)
-		if err != nil {
-			log.Printf("Error parsing RSS %s (%s): %v", source.URL, source.Name, err)
-			continue // Log error, but don't stop
+		u, err := url.Parse(strings.TrimSpace(s.URL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Errorf("%s: invalid url %q", label, s.URL))
+		} else if prev, dup := seen[u.String()]; dup {
+			problems = append(problems, fmt.Errorf("%s: duplicate url (also used by %s)", label, prev))
+		} else {
+			seen[u.String()] = label
 		}
 
-		// Wrap each item with source metadata
-		for _, item := range feed.Items {
-			feedItem := &FeedItem{
-				Item:   item,
-				Source: &source,
-			}
-			allItems = append(allItems, feedItem)
+		switch s.Lang {
+		case "da", "en", "uk":
+		case "":
+			problems = append(problems, fmt.Errorf("%s: lang is empty", label))
+		default:
+			problems = append(problems, fmt.Errorf("%s: unsupported lang %q (expected da, en or uk)", label, s.Lang))
 		}
 
-		successCount++
-		log.Printf("Loaded %d news from %s (%s)", len(feed.Items), source.Name, source.URL)
+		if s.Priority < 0 || s.Priority > 100 {
+			problems = append(problems, fmt.Errorf("%s: priority must be between 0 and 100", label))
+		}
+		if s.Weight < 0 {
+			problems = append(problems, fmt.Errorf("%s: weight must not be negative", label))
+		}
+		for _, c := range s.AllowedCategories {
+			for _, b := range s.BlockedCategories {
+				if strings.EqualFold(strings.TrimSpace(c), strings.TrimSpace(b)) {
+					problems = append(problems, fmt.Errorf("%s: category %q is both allowed and blocked", label, c))
+				}
+			}
+		}
 	}
+	return problems
+}
+
+// CheckFeed downloads and parses a single feed, returning the number of items
+func CheckFeed(source FeedSource, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	log.Printf("Processed RSS feeds: %d/%d ok", successCount, len(sources))
-	return allItems, nil
+	feed, err := gofeed.NewParser().ParseURLWithContext(source.URL, ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(feed.Items), nil
 }
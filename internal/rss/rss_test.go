@@ -0,0 +1,142 @@
+package rss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deusflow/News/internal/metrics"
+	"github.com/mmcdole/gofeed"
+)
+
+func TestValidateFeeds(t *testing.T) {
+	feeds := []FeedSource{
+		{URL: "https://www.dr.dk/nyheder/service/feeds/allenyheder", Name: "DR", Lang: "da", Active: true},
+		{URL: "https://www.dr.dk/nyheder/service/feeds/allenyheder", Name: "DR copy", Lang: "da"},
+		{URL: "ftp://example.com/feed", Name: "", Lang: "de", Priority: -1, Weight: -2,
+			AllowedCategories: []string{"tech"}, BlockedCategories: []string{"Tech"}},
+	}
+
+	problems := ValidateFeeds(feeds)
+	var msgs []string
+	for _, p := range problems {
+		msgs = append(msgs, p.Error())
+	}
+	joined := strings.Join(msgs, "\n")
+	for _, want := range []string{"duplicate url", "name is empty", "invalid url", "unsupported lang", "priority must be between 0 and 100",
+		"weight must not be negative", "both allowed and blocked"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected problem %q, got:\n%s", want, joined)
+		}
+	}
+	if len(ValidateFeeds(feeds[:1])) != 0 {
+		t.Errorf("valid feed reported as invalid")
+	}
+}
+
+const testFeed = `<?xml version="1.0"?><rss version="2.0"><channel><title>t</title>
+<item><title>Første nyhed</title><link>https://example.com/1</link></item>
+<item><title>Anden nyhed</title><link>https://example.com/2</link></item>
+</channel></rss>`
+
+func TestFetchFeeds_ConditionalGet(t *testing.T) {
+	full := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(testFeed))
+	}))
+	defer srv.Close()
+
+	sources := []FeedSource{
+		{URL: srv.URL + "/feed", Name: "ok", Active: true},
+		{URL: srv.URL + "/broken", Name: "broken", Active: true},
+		{URL: srv.URL + "/off", Name: "off"},
+	}
+	opts := FetchOptions{Concurrency: 2, States: NewFileStateStore(filepath.Join(t.TempDir(), "feed_state.json"))}
+
+	for run, want := range []string{metrics.FeedOK, metrics.FeedNotModified} {
+		items, results, err := FetchFeeds(context.Background(), sources, opts)
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if len(items) != 2 || items[0].Source.Name != "ok" {
+			t.Fatalf("run %d: got %d items", run, len(items))
+		}
+		if len(results) != 2 || results[0].Status != want || results[0].Items != 2 {
+			t.Errorf("run %d: unexpected result %+v", run, results[0])
+		}
+		if results[1].Status != metrics.FeedFailed || results[1].HTTPStatus != 500 || results[1].Error == "" {
+			t.Errorf("run %d: broken feed result %+v", run, results[1])
+		}
+	}
+	if full != 1 {
+		t.Errorf("feed body downloaded %d times, want 1 (second run must be 304)", full)
+	}
+}
+
+func TestFetchFeeds_DisablesFailingFeed(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte("<html>moved</html>"))
+	}))
+	defer srv.Close()
+
+	sources := []FeedSource{{URL: srv.URL, Name: "moved", Active: true}}
+	store := NewFileStateStore(filepath.Join(t.TempDir(), "feed_state.json"))
+	opts := FetchOptions{States: store, DisableAfter: 2, ReprobeInterval: time.Hour}
+
+	for run, want := range []string{metrics.FeedParseError, metrics.FeedParseError, metrics.FeedDisabled} {
+		_, results, err := FetchFeeds(context.Background(), sources, opts)
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if results[0].Status != want {
+			t.Errorf("run %d: status %q, want %q", run, results[0].Status, want)
+		}
+	}
+	if hits != 2 {
+		t.Errorf("disabled feed was requested: %d hits, want 2", hits)
+	}
+
+	states, _ := store.LoadFeedStates()
+	h := states[srv.URL].Health
+	if h.ConsecutiveFailures != 2 || h.ParseErrors != 2 || !h.Disabled(time.Now()) || h.Disabled(time.Now().Add(2*time.Hour)) {
+		t.Errorf("unexpected health %+v", h)
+	}
+	report := BuildHealthReport(sources, states, 0, time.Now())
+	if len(report) != 1 || report[0].Status != "disabled" {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestFeedHealth_Stale(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-5 * 24 * time.Hour)
+	future := now.Add(48 * time.Hour)
+
+	var h FeedHealth
+	h.recordSuccess([]*FeedItem{
+		{Item: &gofeed.Item{PublishedParsed: &old}},
+		{Item: &gofeed.Item{PublishedParsed: &future}},
+	}, now)
+	if !h.NewestItem.Equal(old) {
+		t.Errorf("newest item %v, future dates must be ignored", h.NewestItem)
+	}
+	if !h.Stale(now, 3*24*time.Hour) || h.Stale(now, 7*24*time.Hour) || h.Stale(now, 0) {
+		t.Errorf("unexpected stale result for newest item %v", h.NewestItem)
+	}
+}
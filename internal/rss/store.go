@@ -0,0 +1,57 @@
+package rss
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileStateStore keeps feed caching state in a JSON file (for runs without PostgreSQL)
+type FileStateStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStateStore creates a file-backed feed state store
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{path: path}
+}
+
+// LoadFeedStates reads state from file; missing or empty file means no state yet
+func (fs *FileStateStore) LoadFeedStates() (map[string]FeedState, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	data, err := os.ReadFile(fs.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed state file: %v", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var states map[string]FeedState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal feed state: %v", err)
+	}
+	return states, nil
+}
+
+// SaveFeedStates writes state to file
+func (fs *FileStateStore) SaveFeedStates(states map[string]FeedState) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal feed state: %v", err)
+	}
+	if err := os.WriteFile(fs.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write feed state file: %v", err)
+	}
+	return nil
+}
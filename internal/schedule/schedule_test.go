@@ -0,0 +1,53 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC) // Friday
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 8 * * *", time.Date(2025, 3, 15, 8, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 3, 14, 9, 45, 0, 0, time.UTC)},
+		{"0 8,12,18 * * *", time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)},
+		{"30 7-9 * * 1-5", time.Date(2025, 3, 17, 7, 30, 0, 0, time.UTC)},
+		{"0 10 * * 7", time.Date(2025, 3, 16, 10, 0, 0, 0, time.UTC)},
+		{"0 0 1 4 *", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 8 */2 * 1", time.Date(2025, 3, 17, 8, 0, 0, 0, time.UTC)}, // odd day AND Monday, not the 15th
+	}
+	for _, tc := range cases {
+		c, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := c.Next(from); !got.Equal(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"0 8 * *", "61 * * * *", "* 5-2 * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCron(bad); err == nil {
+			t.Errorf("%q: expected parse error", bad)
+		}
+	}
+}
+
+func TestScheduleSkipsQuietHours(t *testing.T) {
+	s, err := New([]string{"0 */3 * * *"}, "22:00-07:00", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2025, 3, 14, 21, 10, 0, 0, time.UTC)
+	want := time.Date(2025, 3, 15, 9, 0, 0, 0, time.UTC)
+	if got := s.Next(from); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := New([]string{"0 8 * * *"}, "22-07", time.UTC); err == nil {
+		t.Errorf("expected quiet hours parse error")
+	}
+}
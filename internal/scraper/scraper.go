@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -20,15 +21,30 @@ type ArticleContent struct {
 	URL     string
 }
 
-// ExtractFullArticle gets full text of article by URL
-func ExtractFullArticle(url string) (*ArticleContent, error) {
-	// Make HTTP client with timeout
-	client := &http.Client{
-		Timeout: 15 * time.Second,
+// requestTimeout bounds a single page download
+var requestTimeout = 15 * time.Second
+
+// SetRequestTimeout changes the per-page timeout (d <= 0 keeps the current value)
+func SetRequestTimeout(d time.Duration) {
+	if d > 0 {
+		requestTimeout = d
+	}
+}
+
+// getPage downloads url; the request is aborted when ctx is cancelled
+func getPage(ctx context.Context, url string) (*http.Response, error) {
+	client := &http.Client{Timeout: requestTimeout}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
 	}
+	return client.Do(req)
+}
 
+// ExtractFullArticle gets full text of article by URL
+func ExtractFullArticle(ctx context.Context, url string) (*ArticleContent, error) {
 	// Get HTML page
-	resp, err := client.Get(url)
+	resp, err := getPage(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("error loading page: %v", err)
 	}
@@ -462,12 +478,13 @@ func cleanContent(content string) string {
 }
 
 // ExtractArticlesInBackground gets full content of articles in background (defaults)
-func ExtractArticlesInBackground(urls []string) map[string]*ArticleContent {
-	return ExtractArticlesInBackgroundWithLimits(urls, 10, 8)
+func ExtractArticlesInBackground(ctx context.Context, urls []string) map[string]*ArticleContent {
+	return ExtractArticlesInBackgroundWithLimits(ctx, urls, 10, 8)
 }
 
-// ExtractArticlesInBackgroundWithLimits gets full content with configurable parallelism and cap
-func ExtractArticlesInBackgroundWithLimits(urls []string, maxArticles, concurrency int) map[string]*ArticleContent {
+// ExtractArticlesInBackgroundWithLimits gets full content with configurable parallelism and cap.
+// Once ctx is cancelled remaining URLs are skipped and the partial result is returned.
+func ExtractArticlesInBackgroundWithLimits(ctx context.Context, urls []string, maxArticles, concurrency int) map[string]*ArticleContent {
 	result := make(map[string]*ArticleContent)
 	if maxArticles <= 0 {
 		maxArticles = 10
@@ -493,8 +510,11 @@ func ExtractArticlesInBackgroundWithLimits(urls []string, maxArticles, concurren
 	worker := func(id int) {
 		defer wg.Done()
 		for j := range jobs {
+			if ctx.Err() != nil {
+				continue
+			}
 			log.Printf("[scraper] worker %d: fetching %s", id, j.u)
-			article, err := ExtractFullArticle(j.u)
+			article, err := ExtractFullArticle(ctx, j.u)
 			if err == nil && article != nil && len(article.Content) > 100 {
 				mu.Lock()
 				result[j.u] = article
@@ -506,7 +526,10 @@ func ExtractArticlesInBackgroundWithLimits(urls []string, maxArticles, concurren
 				log.Printf("⚠️ Content too short: %s", j.u)
 			}
 			// Tiny backoff between jobs to be gentle
-			time.Sleep(200 * time.Millisecond)
+			select {
+			case <-ctx.Done():
+			case <-time.After(200 * time.Millisecond):
+			}
 		}
 	}
 
@@ -524,13 +547,12 @@ func ExtractArticlesInBackgroundWithLimits(urls []string, maxArticles, concurren
 }
 
 // ExtractImageURL fetches a page and tries to detect a representative image (og:image/twitter:image)
-func ExtractImageURL(pageURL string) (string, error) {
+func ExtractImageURL(ctx context.Context, pageURL string) (string, error) {
 	if strings.TrimSpace(pageURL) == "" {
 		return "", fmt.Errorf("empty url")
 	}
 
-	client := &http.Client{Timeout: 12 * time.Second}
-	resp, err := client.Get(pageURL)
+	resp, err := getPage(ctx, pageURL)
 	if err != nil {
 		return "", fmt.Errorf("error loading page: %v", err)
 	}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +20,12 @@ type SentNewsItem struct {
 	Category string    `json:"category"`
 	SentAt   time.Time `json:"sent_at"`
 	Source   string    `json:"source"`
+
+	Fingerprint uint64 `json:"fingerprint,omitempty"` // SimHash of the story (news.Fingerprint)
+	MessageID   int64  `json:"message_id,omitempty"`  // Telegram message_id of the post
+	ChatID      string `json:"chat_id,omitempty"`     // chat the message was posted to
+	Channel     string `json:"channel,omitempty"`     // posting channel name ("" = first/only channel)
+	Content     string `json:"content,omitempty"`     // title and feed description as first posted (update detection)
 }
 
 // FileCache manages sent news items in a JSON file
@@ -110,6 +117,17 @@ func (fc *FileCache) GenerateNewsHash(title, link string) string {
 	return hex.EncodeToString(h.Sum(nil))[:16] // Use first 16 characters
 }
 
+// ScopedHash makes a news hash specific to a posting channel; the legacy_history channel ("")
+// keeps the plain hash so records from single-channel setups stay valid
+func ScopedHash(hash, channel string) string {
+	if channel == "" {
+		return hash
+	}
+	h := sha256.New()
+	h.Write([]byte(channel + "|" + hash))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
 // IsAlreadySent checks if news was already sent
 func (fc *FileCache) IsAlreadySent(hash string) bool {
 	fc.mu.RLock()
@@ -125,19 +143,29 @@ func (fc *FileCache) IsAlreadySent(hash string) bool {
 	return item.SentAt.After(cutoffTime)
 }
 
-// MarkAsSent marks news as sent
-func (fc *FileCache) MarkAsSent(hash, title, link, category, source string) {
+// MarkAsSent marks news as sent (SentAt defaults to now)
+func (fc *FileCache) MarkAsSent(item SentNewsItem) {
 	fc.mu.Lock()
 	defer fc.mu.Unlock()
 
-	fc.items[hash] = SentNewsItem{
-		Hash:     hash,
-		Title:    title,
-		Link:     link,
-		Category: category,
-		SentAt:   time.Now(),
-		Source:   source,
+	if item.SentAt.IsZero() {
+		item.SentAt = time.Now()
 	}
+	fc.items[item.Hash] = item
+}
+
+// SentSince returns items sent after since (for near-duplicate checks)
+func (fc *FileCache) SentSince(since time.Time) []SentNewsItem {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+
+	var items []SentNewsItem
+	for _, item := range fc.items {
+		if item.SentAt.After(since) {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // Cleanup removes expired items from memory
@@ -185,3 +213,32 @@ func extractDomain(url string) string {
 
 	return strings.ToLower(domain)
 }
+
+// Items returns all cached items, newest first
+func (fc *FileCache) Items() []SentNewsItem {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+
+	items := make([]SentNewsItem, 0, len(fc.items))
+	for _, item := range fc.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].SentAt.After(items[j].SentAt) })
+	return items
+}
+
+// Purge removes expired items, or every item when all is true; returns how many were removed
+func (fc *FileCache) Purge(all bool) int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	cutoffTime := time.Now().Add(-time.Duration(fc.ttlHours) * time.Hour)
+	removed := 0
+	for hash, item := range fc.items {
+		if all || item.SentAt.Before(cutoffTime) {
+			delete(fc.items, hash)
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// photoCacheTTL is how long an unused file_id is kept
+const photoCacheTTL = 30 * 24 * time.Hour
+
+// PhotoStore remembers the Telegram file_id of uploaded images, so posting the same image again
+// does not download and upload it again. Implemented by PostgresCache and FilePhotoCache.
+// GetPhotoFileID returns "" when the image was never uploaded.
+type PhotoStore interface {
+	GetPhotoFileID(imageURL string) (string, error)
+	SetPhotoFileID(imageURL, fileID string) error
+}
+
+// PhotoCacheItem is one uploaded image
+type PhotoCacheItem struct {
+	URL        string    `json:"url"`
+	FileID     string    `json:"file_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// FilePhotoCache keeps file_ids in a JSON file (for runs without PostgreSQL)
+type FilePhotoCache struct {
+	filePath string
+	items    map[string]PhotoCacheItem
+	mu       sync.RWMutex
+}
+
+// NewFilePhotoCache creates a new file-backed photo cache
+func NewFilePhotoCache(filePath string) *FilePhotoCache {
+	return &FilePhotoCache{
+		filePath: filePath,
+		items:    make(map[string]PhotoCacheItem),
+	}
+}
+
+// Load loads file_ids from file, dropping entries unused for too long
+func (pc *FilePhotoCache) Load() error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	data, err := os.ReadFile(pc.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read photo cache file: %v", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var items []PhotoCacheItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("failed to unmarshal photo cache: %v", err)
+	}
+
+	cutoffTime := time.Now().Add(-photoCacheTTL)
+	for _, item := range items {
+		if item.LastUsedAt.After(cutoffTime) {
+			pc.items[item.URL] = item
+		}
+	}
+	return nil
+}
+
+// Save writes file_ids to file
+func (pc *FilePhotoCache) Save() error {
+	pc.mu.RLock()
+	items := make([]PhotoCacheItem, 0, len(pc.items))
+	for _, item := range pc.items {
+		items = append(items, item)
+	}
+	pc.mu.RUnlock()
+
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal photo cache: %v", err)
+	}
+	if err := os.WriteFile(pc.filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write photo cache file: %v", err)
+	}
+	return nil
+}
+
+// GetPhotoFileID returns the cached file_id of imageURL and marks it as used
+func (pc *FilePhotoCache) GetPhotoFileID(imageURL string) (string, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	item, ok := pc.items[imageURL]
+	if !ok {
+		return "", nil
+	}
+	item.LastUsedAt = time.Now()
+	pc.items[imageURL] = item
+	return item.FileID, nil
+}
+
+// SetPhotoFileID stores the file_id Telegram returned for imageURL; an empty fileID forgets the image
+func (pc *FilePhotoCache) SetPhotoFileID(imageURL, fileID string) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if fileID == "" {
+		delete(pc.items, imageURL)
+		return nil
+	}
+	now := time.Now()
+	pc.items[imageURL] = PhotoCacheItem{URL: imageURL, FileID: fileID, CreatedAt: now, LastUsedAt: now}
+	return nil
+}
+
+// GetStats returns cache statistics
+func (pc *FilePhotoCache) GetStats() map[string]int {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	return map[string]int{
+		"total_items": len(pc.items),
+	}
+}
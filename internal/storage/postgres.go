@@ -2,10 +2,14 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/deusflow/News/internal/ratelimit"
+	"github.com/deusflow/News/internal/rss"
+
 	_ "github.com/lib/pq"
 )
 
@@ -17,16 +21,19 @@ type PostgresCache struct {
 
 // TranslationCacheItem represents cached AI translation
 type TranslationCacheItem struct {
-	ContentHash          string
-	Title                string
-	Content              string
-	Summary              string
-	DanishTranslation    string
-	UkrainianTranslation string
-	AIProvider           string
-	CreatedAt            time.Time
-	LastUsedAt           time.Time
-	UseCount             int
+	ContentHash          string    `json:"content_hash"`
+	Title                string    `json:"title"`
+	Content              string    `json:"content"`
+	Summary              string    `json:"summary"`
+	DanishTranslation    string    `json:"danish_translation"`
+	UkrainianTranslation string    `json:"ukrainian_translation"`
+	UkrainianTitle       string    `json:"ukrainian_title,omitempty"`
+	Category             string    `json:"category,omitempty"`
+	Confidence           float64   `json:"confidence,omitempty"`
+	AIProvider           string    `json:"ai_provider"`
+	CreatedAt            time.Time `json:"created_at"`
+	LastUsedAt           time.Time `json:"last_used_at"`
+	UseCount             int       `json:"use_count"`
 }
 
 // NewPostgresCache creates a new PostgreSQL cache instance
@@ -72,6 +79,14 @@ func (pc *PostgresCache) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_sent_news_hash ON sent_news(hash);
 	CREATE INDEX IF NOT EXISTS idx_sent_news_sent_at ON sent_news(sent_at);
 	CREATE INDEX IF NOT EXISTS idx_sent_news_link ON sent_news(link);
+	-- SimHash of title + description for near-duplicate detection across runs
+	ALTER TABLE sent_news ADD COLUMN IF NOT EXISTS fingerprint BIGINT;
+	-- Telegram message and posted text, for follow-up replies when the story is updated
+	ALTER TABLE sent_news ADD COLUMN IF NOT EXISTS message_id BIGINT;
+	ALTER TABLE sent_news ADD COLUMN IF NOT EXISTS content TEXT;
+	ALTER TABLE sent_news ADD COLUMN IF NOT EXISTS chat_id TEXT;
+	-- Posting channel for multi-channel setups ('' = first/only channel)
+	ALTER TABLE sent_news ADD COLUMN IF NOT EXISTS channel TEXT;
 
 	-- Table for caching AI translations (saves tokens!)
 	CREATE TABLE IF NOT EXISTS translation_cache (
@@ -88,8 +103,38 @@ func (pc *PostgresCache) initSchema() error {
 		use_count INTEGER DEFAULT 1
 	);
 
+	-- Structured AI output fields (added later; ALTER keeps existing databases working)
+	ALTER TABLE translation_cache ADD COLUMN IF NOT EXISTS ukrainian_title TEXT;
+	ALTER TABLE translation_cache ADD COLUMN IF NOT EXISTS category VARCHAR(50);
+	ALTER TABLE translation_cache ADD COLUMN IF NOT EXISTS confidence REAL;
+
 	CREATE INDEX IF NOT EXISTS idx_translation_cache_hash ON translation_cache(content_hash);
 	CREATE INDEX IF NOT EXISTS idx_translation_cache_created_at ON translation_cache(created_at);
+
+	-- AI rate limiter state (daily quotas survive cron-style runs)
+	CREATE TABLE IF NOT EXISTS ai_rate_limits (
+		name VARCHAR(50) PRIMARY KEY,
+		state JSONB NOT NULL,
+		updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+
+	-- Conditional GET state per feed (ETag/Last-Modified and last body)
+	CREATE TABLE IF NOT EXISTS feed_state (
+		url TEXT PRIMARY KEY,
+		etag TEXT,
+		last_modified TEXT,
+		body TEXT,
+		fetched_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+	ALTER TABLE feed_state ADD COLUMN IF NOT EXISTS health JSONB;
+
+	-- Telegram file_id of uploaded images (repeats are sent without upload)
+	CREATE TABLE IF NOT EXISTS telegram_photos (
+		url TEXT PRIMARY KEY,
+		file_id TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		last_used_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
 	`
 
 	_, err := pc.db.Exec(schema)
@@ -117,13 +162,13 @@ func (pc *PostgresCache) IsAlreadySent(hash string) bool {
 	return count > 0
 }
 
-// IsLinkAlreadySent checks if a specific link was already sent (additional safety check)
-func (pc *PostgresCache) IsLinkAlreadySent(link string) bool {
+// IsLinkAlreadySent checks if a specific link was already sent to channel (additional safety check)
+func (pc *PostgresCache) IsLinkAlreadySent(link, channel string) bool {
 	cutoffTime := time.Now().Add(-time.Duration(pc.ttlHours) * time.Hour)
 
 	var count int
-	query := `SELECT COUNT(*) FROM sent_news WHERE link = $1 AND sent_at > $2`
-	err := pc.db.QueryRow(query, link, cutoffTime).Scan(&count)
+	query := `SELECT COUNT(*) FROM sent_news WHERE link = $1 AND sent_at > $2 AND COALESCE(channel, '') = $3`
+	err := pc.db.QueryRow(query, link, cutoffTime, channel).Scan(&count)
 
 	if err != nil {
 		log.Printf("⚠️ Error checking link duplicate: %v", err)
@@ -134,15 +179,18 @@ func (pc *PostgresCache) IsLinkAlreadySent(link string) bool {
 }
 
 // MarkAsSent marks news as sent with transaction to prevent race conditions
-func (pc *PostgresCache) MarkAsSent(hash, title, link, category, source string) error {
+func (pc *PostgresCache) MarkAsSent(item SentNewsItem) error {
 	// Use INSERT ON CONFLICT to handle race conditions
 	query := `
-		INSERT INTO sent_news (hash, title, link, category, source, sent_at)
-		VALUES ($1, $2, $3, $4, $5, NOW())
-		ON CONFLICT (hash) DO UPDATE SET sent_at = NOW()
+		INSERT INTO sent_news (hash, title, link, category, source, fingerprint, message_id, chat_id, channel, content, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+		ON CONFLICT (hash) DO UPDATE SET sent_at = NOW(), fingerprint = EXCLUDED.fingerprint,
+			message_id = EXCLUDED.message_id, chat_id = EXCLUDED.chat_id, content = EXCLUDED.content
 	`
 
-	_, err := pc.db.Exec(query, hash, title, link, category, source)
+	// BIGINT is signed; the fingerprint bits are stored as is
+	_, err := pc.db.Exec(query, item.Hash, item.Title, item.Link, item.Category, item.Source,
+		int64(item.Fingerprint), item.MessageID, item.ChatID, item.Channel, item.Content)
 	if err != nil {
 		return fmt.Errorf("failed to mark as sent: %v", err)
 	}
@@ -150,6 +198,17 @@ func (pc *PostgresCache) MarkAsSent(hash, title, link, category, source string)
 	return nil
 }
 
+// SentSince returns news sent after since (for near-duplicate checks)
+func (pc *PostgresCache) SentSince(since time.Time) ([]SentNewsItem, error) {
+	return pc.querySentNews(`
+		SELECT hash, title, link, COALESCE(category, ''), COALESCE(source, ''), COALESCE(fingerprint, 0),
+			COALESCE(message_id, 0), COALESCE(chat_id, ''), COALESCE(channel, ''), COALESCE(content, ''), sent_at
+		FROM sent_news
+		WHERE sent_at > $1
+		ORDER BY sent_at DESC
+	`, since)
+}
+
 // Cleanup removes expired items from database
 func (pc *PostgresCache) Cleanup() error {
 	cutoffTime := time.Now().Add(-time.Duration(pc.ttlHours) * time.Hour)
@@ -165,6 +224,23 @@ func (pc *PostgresCache) Cleanup() error {
 		log.Printf("🗑️ Cleaned up %d old records from database", rows)
 	}
 
+	// Translations nobody asked for in a long time
+	result, err = pc.db.Exec(`DELETE FROM translation_cache WHERE last_used_at < $1`, time.Now().Add(-translationCacheTTL))
+	if err != nil {
+		return fmt.Errorf("failed to cleanup translation cache: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		log.Printf("🗑️ Cleaned up %d unused cached translations", rows)
+	}
+
+	result, err = pc.db.Exec(`DELETE FROM telegram_photos WHERE last_used_at < $1`, time.Now().Add(-photoCacheTTL))
+	if err != nil {
+		return fmt.Errorf("failed to cleanup photo cache: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		log.Printf("🗑️ Cleaned up %d unused photo file_ids", rows)
+	}
+
 	return nil
 }
 
@@ -243,6 +319,51 @@ func (pc *PostgresCache) GetRecentNews(limit int) ([]SentNewsItem, error) {
 	return items, nil
 }
 
+// ListSentNews returns every sent news record, newest first (for export)
+func (pc *PostgresCache) ListSentNews() ([]SentNewsItem, error) {
+	return pc.querySentNews(`
+		SELECT hash, title, link, COALESCE(category, ''), COALESCE(source, ''), COALESCE(fingerprint, 0),
+			COALESCE(message_id, 0), COALESCE(chat_id, ''), COALESCE(channel, ''), COALESCE(content, ''), sent_at
+		FROM sent_news
+		ORDER BY sent_at DESC
+	`)
+}
+
+func (pc *PostgresCache) querySentNews(query string, args ...interface{}) ([]SentNewsItem, error) {
+	rows, err := pc.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sent news: %v", err)
+	}
+	defer rows.Close()
+
+	var items []SentNewsItem
+	for rows.Next() {
+		var item SentNewsItem
+		var fingerprint int64
+		if err := rows.Scan(&item.Hash, &item.Title, &item.Link, &item.Category, &item.Source, &fingerprint,
+			&item.MessageID, &item.ChatID, &item.Channel, &item.Content, &item.SentAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sent news: %v", err)
+		}
+		item.Fingerprint = uint64(fingerprint)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Purge deletes expired sent news, or all sent news when all is true; returns deleted row count
+func (pc *PostgresCache) Purge(all bool) (int64, error) {
+	query := `DELETE FROM sent_news WHERE sent_at < $1`
+	args := []interface{}{time.Now().Add(-time.Duration(pc.ttlHours) * time.Hour)}
+	if all {
+		query, args = `DELETE FROM sent_news`, nil
+	}
+	result, err := pc.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge sent news: %v", err)
+	}
+	return result.RowsAffected()
+}
+
 // Close closes the database connection
 func (pc *PostgresCache) Close() error {
 	if pc.db != nil {
@@ -258,19 +379,22 @@ func (pc *PostgresCache) GenerateNewsHash(title, link string) string {
 	return fc.GenerateNewsHash(title, link)
 }
 
-// GetTranslationCache retrieves translation from cache
+// GetTranslationCache retrieves translation from cache and bumps use_count/last_used_at
 func (pc *PostgresCache) GetTranslationCache(contentHash string) (TranslationCacheItem, error) {
 	var item TranslationCacheItem
 
 	query := `
-		SELECT content_hash, title, content, summary, danish_translation, ukrainian_translation, ai_provider, created_at, last_used_at, use_count
-		FROM translation_cache
+		UPDATE translation_cache
+		SET last_used_at = NOW(), use_count = use_count + 1
 		WHERE content_hash = $1
+		RETURNING content_hash, title, content, COALESCE(summary, ''), COALESCE(danish_translation, ''),
+			COALESCE(ukrainian_translation, ''), COALESCE(ukrainian_title, ''), COALESCE(category, ''), COALESCE(confidence, 0),
+			COALESCE(ai_provider, ''), created_at, last_used_at, use_count
 	`
 
 	err := pc.db.QueryRow(query, contentHash).Scan(
 		&item.ContentHash, &item.Title, &item.Content, &item.Summary,
-		&item.DanishTranslation, &item.UkrainianTranslation, &item.AIProvider,
+		&item.DanishTranslation, &item.UkrainianTranslation, &item.UkrainianTitle, &item.Category, &item.Confidence, &item.AIProvider,
 		&item.CreatedAt, &item.LastUsedAt, &item.UseCount,
 	)
 
@@ -288,23 +412,156 @@ func (pc *PostgresCache) GetTranslationCache(contentHash string) (TranslationCac
 func (pc *PostgresCache) SetTranslationCache(item TranslationCacheItem) error {
 	// Use INSERT ON CONFLICT to handle updates
 	query := `
-		INSERT INTO translation_cache (content_hash, title, content, summary, danish_translation, ukrainian_translation, ai_provider, created_at, last_used_at, use_count)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW(), 1)
+		INSERT INTO translation_cache (content_hash, title, content, summary, danish_translation, ukrainian_translation,
+			ukrainian_title, category, confidence, ai_provider, created_at, last_used_at, use_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW(), 1)
 		ON CONFLICT (content_hash) DO UPDATE SET
 			title = EXCLUDED.title,
 			content = EXCLUDED.content,
 			summary = EXCLUDED.summary,
 			danish_translation = EXCLUDED.danish_translation,
 			ukrainian_translation = EXCLUDED.ukrainian_translation,
+			ukrainian_title = EXCLUDED.ukrainian_title,
+			category = EXCLUDED.category,
+			confidence = EXCLUDED.confidence,
 			ai_provider = EXCLUDED.ai_provider,
 			last_used_at = NOW(),
 			use_count = translation_cache.use_count + 1
 	`
 
-	_, err := pc.db.Exec(query, item.ContentHash, item.Title, item.Content, item.Summary, item.DanishTranslation, item.UkrainianTranslation, item.AIProvider)
+	_, err := pc.db.Exec(query, item.ContentHash, item.Title, item.Content, item.Summary, item.DanishTranslation, item.UkrainianTranslation,
+		item.UkrainianTitle, item.Category, item.Confidence, item.AIProvider)
 	if err != nil {
 		return fmt.Errorf("failed to set translation cache: %v", err)
 	}
 
 	return nil
 }
+
+// GetPhotoFileID returns the cached file_id of imageURL and marks it as used ("" if not cached)
+func (pc *PostgresCache) GetPhotoFileID(imageURL string) (string, error) {
+	var fileID string
+	err := pc.db.QueryRow(`
+		UPDATE telegram_photos SET last_used_at = NOW()
+		WHERE url = $1
+		RETURNING file_id
+	`, imageURL).Scan(&fileID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get photo file_id: %v", err)
+	}
+	return fileID, nil
+}
+
+// SetPhotoFileID stores the file_id Telegram returned for imageURL; an empty fileID forgets the image
+func (pc *PostgresCache) SetPhotoFileID(imageURL, fileID string) error {
+	var err error
+	if fileID == "" {
+		_, err = pc.db.Exec(`DELETE FROM telegram_photos WHERE url = $1`, imageURL)
+	} else {
+		_, err = pc.db.Exec(`
+			INSERT INTO telegram_photos (url, file_id, created_at, last_used_at)
+			VALUES ($1, $2, NOW(), NOW())
+			ON CONFLICT (url) DO UPDATE SET file_id = EXCLUDED.file_id, last_used_at = NOW()
+		`, imageURL, fileID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set photo file_id: %v", err)
+	}
+	return nil
+}
+
+// rateLimitStateName is the ai_rate_limits row used by the bot
+const rateLimitStateName = "default"
+
+// LoadRateLimitState reads AI rate limiter state (nil if nothing stored yet)
+func (pc *PostgresCache) LoadRateLimitState() (*ratelimit.State, error) {
+	var raw []byte
+	err := pc.db.QueryRow(`SELECT state FROM ai_rate_limits WHERE name = $1`, rateLimitStateName).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rate limit state: %v", err)
+	}
+
+	var state ratelimit.State
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rate limit state: %v", err)
+	}
+	return &state, nil
+}
+
+// SaveRateLimitState upserts AI rate limiter state
+func (pc *PostgresCache) SaveRateLimitState(state ratelimit.State) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rate limit state: %v", err)
+	}
+
+	query := `
+		INSERT INTO ai_rate_limits (name, state, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE SET state = EXCLUDED.state, updated_at = NOW()
+	`
+	if _, err := pc.db.Exec(query, rateLimitStateName, raw); err != nil {
+		return fmt.Errorf("failed to save rate limit state: %v", err)
+	}
+	return nil
+}
+
+// LoadFeedStates reads conditional GET state and health of all feeds
+func (pc *PostgresCache) LoadFeedStates() (map[string]rss.FeedState, error) {
+	rows, err := pc.db.Query(`SELECT url, COALESCE(etag, ''), COALESCE(last_modified, ''), COALESCE(body, ''), fetched_at, health FROM feed_state`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feed state: %v", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string]rss.FeedState)
+	for rows.Next() {
+		var url string
+		var health []byte
+		var st rss.FeedState
+		if err := rows.Scan(&url, &st.ETag, &st.LastModified, &st.Body, &st.FetchedAt, &health); err != nil {
+			return nil, fmt.Errorf("failed to scan feed state: %v", err)
+		}
+		if len(health) > 0 {
+			if err := json.Unmarshal(health, &st.Health); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal feed health for %s: %v", url, err)
+			}
+		}
+		states[url] = st
+	}
+	return states, rows.Err()
+}
+
+// SaveFeedStates replaces stored feed state with states
+func (pc *PostgresCache) SaveFeedStates(states map[string]rss.FeedState) error {
+	tx, err := pc.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save feed state: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM feed_state`); err != nil {
+		return fmt.Errorf("failed to save feed state: %v", err)
+	}
+	for url, st := range states {
+		health, err := json.Marshal(st.Health)
+		if err != nil {
+			return fmt.Errorf("failed to marshal feed health: %v", err)
+		}
+		_, err = tx.Exec(`INSERT INTO feed_state (url, etag, last_modified, body, fetched_at, health) VALUES ($1, $2, $3, $4, $5, $6)`,
+			url, st.ETag, st.LastModified, st.Body, st.FetchedAt, health)
+		if err != nil {
+			return fmt.Errorf("failed to save feed state for %s: %v", url, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save feed state: %v", err)
+	}
+	return nil
+}
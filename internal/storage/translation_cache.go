@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// translationCacheTTL is how long an unused cached translation is kept
+const translationCacheTTL = 30 * 24 * time.Hour
+
+// TranslationStore is a durable cache for AI results, implemented by PostgresCache and FileTranslationCache.
+// GetTranslationCache returns a zero item (empty ContentHash) when nothing is cached.
+type TranslationStore interface {
+	GetTranslationCache(contentHash string) (TranslationCacheItem, error)
+	SetTranslationCache(item TranslationCacheItem) error
+}
+
+// ContentHash builds a stable cache key from request parts (operation, languages, text...)
+func ContentHash(parts ...string) string {
+	h := sha256.New()
+	h.Write([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FileTranslationCache keeps cached AI translations in a JSON file (for runs without PostgreSQL)
+type FileTranslationCache struct {
+	filePath string
+	items    map[string]TranslationCacheItem
+	mu       sync.RWMutex
+}
+
+// NewFileTranslationCache creates a new file-backed translation cache
+func NewFileTranslationCache(filePath string) *FileTranslationCache {
+	return &FileTranslationCache{
+		filePath: filePath,
+		items:    make(map[string]TranslationCacheItem),
+	}
+}
+
+// Load loads cached translations from file, dropping entries unused for too long
+func (tc *FileTranslationCache) Load() error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	data, err := os.ReadFile(tc.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read translation cache file: %v", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	var items []TranslationCacheItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("failed to unmarshal translation cache: %v", err)
+	}
+
+	cutoffTime := time.Now().Add(-translationCacheTTL)
+	for _, item := range items {
+		if item.LastUsedAt.After(cutoffTime) {
+			tc.items[item.ContentHash] = item
+		}
+	}
+	return nil
+}
+
+// Save writes cached translations to file
+func (tc *FileTranslationCache) Save() error {
+	tc.mu.RLock()
+	items := make([]TranslationCacheItem, 0, len(tc.items))
+	for _, item := range tc.items {
+		items = append(items, item)
+	}
+	tc.mu.RUnlock()
+
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal translation cache: %v", err)
+	}
+	if err := os.WriteFile(tc.filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write translation cache file: %v", err)
+	}
+	return nil
+}
+
+// GetTranslationCache returns cached item and bumps its usage counters
+func (tc *FileTranslationCache) GetTranslationCache(contentHash string) (TranslationCacheItem, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	item, ok := tc.items[contentHash]
+	if !ok {
+		return TranslationCacheItem{}, nil
+	}
+	item.LastUsedAt = time.Now()
+	item.UseCount++
+	tc.items[contentHash] = item
+	return item, nil
+}
+
+// SetTranslationCache stores or updates an item
+func (tc *FileTranslationCache) SetTranslationCache(item TranslationCacheItem) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := tc.items[item.ContentHash]; ok {
+		item.CreatedAt = existing.CreatedAt
+		item.UseCount = existing.UseCount + 1
+	} else {
+		item.CreatedAt = now
+		item.UseCount = 1
+	}
+	item.LastUsedAt = now
+	tc.items[item.ContentHash] = item
+	return nil
+}
+
+// GetStats returns cache statistics
+func (tc *FileTranslationCache) GetStats() map[string]int {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+
+	return map[string]int{
+		"total_items": len(tc.items),
+	}
+}
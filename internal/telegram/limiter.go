@@ -0,0 +1,176 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits are the Bot API flood limits (https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this)
+type Limits struct {
+	GlobalPerSecond int           // messages per second across all chats
+	ChatInterval    time.Duration // minimum gap between two messages in one chat
+	GroupPerMinute  int           // messages per minute in one group or channel
+}
+
+// DefaultLimits are the limits documented by Telegram
+var DefaultLimits = Limits{GlobalPerSecond: 30, ChatInterval: time.Second, GroupPerMinute: 20}
+
+// maxRetryAfter is the longest flood wait we sit out; longer waits fail the send
+const maxRetryAfter = 2 * time.Minute
+
+type chatState struct {
+	last         time.Time
+	recent       []time.Time // sends in the last minute (groups and channels)
+	blockedUntil time.Time   // set from retry_after
+}
+
+// rateLimiter spaces out sends so Telegram does not answer with 429
+type rateLimiter struct {
+	mu     sync.Mutex
+	limits Limits
+	global []time.Time // sends in the last second
+	chats  map[string]*chatState
+}
+
+func newRateLimiter(l Limits) *rateLimiter {
+	return &rateLimiter{limits: l, chats: make(map[string]*chatState)}
+}
+
+// setLimits changes the flood limits; zero fields keep the documented defaults
+func (l *rateLimiter) setLimits(limits Limits) {
+	if limits.GlobalPerSecond <= 0 {
+		limits.GlobalPerSecond = DefaultLimits.GlobalPerSecond
+	}
+	if limits.ChatInterval <= 0 {
+		limits.ChatInterval = DefaultLimits.ChatInterval
+	}
+	if limits.GroupPerMinute <= 0 {
+		limits.GroupPerMinute = DefaultLimits.GroupPerMinute
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+}
+
+// isGroupChat reports whether chatID is a group or channel (negative id or @username);
+// the per-minute limit does not apply to private chats
+func isGroupChat(chatID string) bool {
+	return strings.HasPrefix(chatID, "-") || strings.HasPrefix(chatID, "@")
+}
+
+// prune drops timestamps older than window
+func prune(times []time.Time, now time.Time, window time.Duration) []time.Time {
+	i := 0
+	for i < len(times) && now.Sub(times[i]) >= window {
+		i++
+	}
+	return times[i:]
+}
+
+// reserve records a send of n messages (an album counts every item) to chatID at now and returns 0,
+// or returns how long to wait before trying again
+func (l *rateLimiter) reserve(chatID string, now time.Time, n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	st := l.chats[chatID]
+	if st == nil {
+		st = &chatState{}
+		l.chats[chatID] = st
+	}
+	l.global = prune(l.global, now, time.Second)
+	st.recent = prune(st.recent, now, time.Minute)
+
+	var wait time.Duration
+	if d := st.blockedUntil.Sub(now); d > wait {
+		wait = d
+	}
+	if !st.last.IsZero() {
+		if d := st.last.Add(l.limits.ChatInterval).Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if isGroupChat(chatID) && len(st.recent) > 0 && len(st.recent)+n > l.limits.GroupPerMinute {
+		if d := st.recent[minInt(len(st.recent)+n-l.limits.GroupPerMinute, len(st.recent))-1].Add(time.Minute).Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if len(l.global) > 0 && len(l.global)+n > l.limits.GlobalPerSecond {
+		if d := l.global[minInt(len(l.global)+n-l.limits.GlobalPerSecond, len(l.global))-1].Add(time.Second).Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	st.last = now
+	for i := 0; i < n; i++ {
+		st.recent = append(st.recent, now)
+		l.global = append(l.global, now)
+	}
+	return 0
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// wait blocks until n messages may be sent to chatID
+func (l *rateLimiter) wait(ctx context.Context, chatID string, n int) error {
+	for {
+		d := l.reserve(chatID, time.Now(), n)
+		if d == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+		}
+	}
+}
+
+// block holds back sends to chatID for d (flood control answer)
+func (l *rateLimiter) block(chatID string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	st := l.chats[chatID]
+	if st == nil {
+		st = &chatState{}
+		l.chats[chatID] = st
+	}
+	if until := time.Now().Add(d); until.After(st.blockedUntil) {
+		st.blockedUntil = until
+	}
+}
+
+// IsPermanent reports whether err is a Telegram error that will not go away on retry:
+// bad request (e.g. broken HTML), unauthorized token, bot kicked or chat not found
+func IsPermanent(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Code {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the flood wait requested by Telegram, 0 if none
+func retryAfter(err error) time.Duration {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	l := newRateLimiter(Limits{GlobalPerSecond: 2, ChatInterval: time.Second, GroupPerMinute: 3})
+	now := time.Unix(1700000000, 0)
+
+	if d := l.reserve("@news", now, 1); d != 0 {
+		t.Fatalf("first send should pass, got wait %v", d)
+	}
+	if d := l.reserve("@news", now.Add(200*time.Millisecond), 1); d != 800*time.Millisecond {
+		t.Errorf("per-chat interval: want 800ms wait, got %v", d)
+	}
+	if d := l.reserve("12345", now.Add(200*time.Millisecond), 1); d != 0 {
+		t.Errorf("other chat should pass, got wait %v", d)
+	}
+	if d := l.reserve("67890", now.Add(300*time.Millisecond), 1); d != 700*time.Millisecond {
+		t.Errorf("global limit: want 700ms wait, got %v", d)
+	}
+
+	// 3 messages per minute in a channel
+	l.reserve("@news", now.Add(2*time.Second), 1)
+	l.reserve("@news", now.Add(4*time.Second), 1)
+	if d := l.reserve("@news", now.Add(6*time.Second), 1); d != 54*time.Second {
+		t.Errorf("per-minute limit: want 54s wait, got %v", d)
+	}
+	// private chats have no per-minute limit
+	for i := 0; i < 5; i++ {
+		if d := l.reserve("12345", now.Add(time.Duration(10+2*i)*time.Second), 1); d != 0 {
+			t.Errorf("private chat send %d: got wait %v", i, d)
+		}
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&Error{Code: 400, Description: "Bad Request: can't parse entities"}, true},
+		{&Error{Code: 403, Description: "Forbidden: bot was kicked from the channel chat"}, true},
+		{fmt.Errorf("can't send photo: %w", &Error{Code: 403}), true},
+		{&Error{Code: 429, RetryAfter: 5 * time.Second}, false},
+		{&Error{Code: 502}, false},
+		{fmt.Errorf("error HTTP request: timeout"), false},
+	}
+	for _, c := range cases {
+		if got := IsPermanent(c.err); got != c.want {
+			t.Errorf("IsPermanent(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
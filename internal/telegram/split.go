@@ -0,0 +1,178 @@
+package telegram
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// MaxMessageLength is the Bot API limit for message text (UTF-16 code units)
+const MaxMessageLength = 4096
+
+var tagRe = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9-]*)[^>]*>`)
+
+// textLength counts UTF-16 code units like Telegram does; markup is counted too, so the result is an upper bound
+func textLength(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// openTags returns the tags still open after s, given the tags open before it (opening tags as written)
+func openTags(open []string, s string) []string {
+	result := append([]string(nil), open...)
+	for _, m := range tagRe.FindAllStringSubmatch(s, -1) {
+		name := strings.ToLower(m[2])
+		if m[1] == "" {
+			result = append(result, m[0])
+			continue
+		}
+		for i := len(result) - 1; i >= 0; i-- {
+			if tagName(result[i]) == name {
+				result = append(result[:i], result[i+1:]...)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func tagName(tag string) string {
+	if m := tagRe.FindStringSubmatch(tag); m != nil {
+		return strings.ToLower(m[2])
+	}
+	return ""
+}
+
+// closingTags closes open in reverse order
+func closingTags(open []string) string {
+	var b strings.Builder
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + tagName(open[i]) + ">")
+	}
+	return b.String()
+}
+
+// splitOutsideTags cuts s after every sep that is not inside a tag; pieces keep their separator
+func splitOutsideTags(s, sep string) []string {
+	var pieces []string
+	inTag := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '<':
+			inTag = true
+		case s[i] == '>':
+			inTag = false
+		case !inTag && strings.HasPrefix(s[i:], sep):
+			pieces = append(pieces, s[start:i+len(sep)])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	if start < len(s) {
+		pieces = append(pieces, s[start:])
+	}
+	return pieces
+}
+
+// atoms splits s into tags, entities and single runes, the pieces that can never be cut
+func atoms(s string) []string {
+	var out []string
+	for len(s) > 0 {
+		end := 0
+		switch s[0] {
+		case '<':
+			end = strings.IndexByte(s, '>') + 1
+		case '&':
+			if semi := strings.IndexByte(s, ';'); semi > 0 && semi < 10 {
+				end = semi + 1
+			}
+		}
+		if end <= 0 {
+			_, end = utf8.DecodeRuneInString(s)
+		}
+		out = append(out, s[:end])
+		s = s[end:]
+	}
+	return out
+}
+
+// htmlSplitter packs pieces into chunks, closing tags at the end of a chunk and reopening them in the next
+type htmlSplitter struct {
+	limit   int
+	chunks  []string
+	cur     strings.Builder
+	open    []string
+	hasText bool // cur holds more than the reopened tags
+}
+
+// separators in order of preference: paragraphs, lines, words
+var separators = []string{"\n\n", "\n", " "}
+
+func (s *htmlSplitter) add(piece string, level int) {
+	next := openTags(s.open, piece)
+	if textLength(s.cur.String())+textLength(piece)+textLength(closingTags(next)) <= s.limit {
+		s.cur.WriteString(piece)
+		s.open = next
+		s.hasText = true
+		return
+	}
+	if s.hasText {
+		s.flush()
+		s.add(piece, level)
+		return
+	}
+	// the piece alone is too long: cut it at the next finer level
+	var parts []string
+	if level < len(separators) {
+		parts = splitOutsideTags(piece, separators[level])
+	} else {
+		parts = atoms(piece)
+	}
+	if len(parts) <= 1 {
+		// a single tag or rune longer than the limit; send it as is
+		s.cur.WriteString(piece)
+		s.open = next
+		s.hasText = true
+		return
+	}
+	for _, p := range parts {
+		s.add(p, level+1)
+	}
+}
+
+func (s *htmlSplitter) flush() {
+	chunk := strings.TrimRight(s.cur.String(), " \n") + closingTags(s.open)
+	if strings.TrimSpace(tagRe.ReplaceAllString(chunk, "")) != "" {
+		s.chunks = append(s.chunks, chunk)
+	}
+	s.cur.Reset()
+	for _, tag := range s.open {
+		s.cur.WriteString(tag)
+	}
+	s.hasText = false
+}
+
+// SplitHTML splits an HTML message into parts of at most limit characters (MaxMessageLength if limit <= 0).
+// Parts are cut between paragraphs, then lines, then words, never inside a tag or entity;
+// tags open at a cut are closed at the end of the part and reopened at the start of the next one.
+func SplitHTML(text string, limit int) []string {
+	if limit <= 0 {
+		limit = MaxMessageLength
+	}
+	if textLength(text) <= limit {
+		return []string{text}
+	}
+	s := &htmlSplitter{limit: limit}
+	for _, p := range splitOutsideTags(text, separators[0]) {
+		s.add(p, 1)
+	}
+	if s.hasText {
+		s.flush()
+	}
+	return s.chunks
+}
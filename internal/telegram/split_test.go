@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitHTML(t *testing.T) {
+	short := "<b>hi</b>"
+	if parts := SplitHTML(short, 100); len(parts) != 1 || parts[0] != short {
+		t.Fatalf("short text split: %q", parts)
+	}
+
+	var b strings.Builder
+	for i := 0; i < 30; i++ {
+		b.WriteString(`📰 <a href="https://example.dk/a?x=1&amp;y=2">Nyhed om skoler</a>` + "\n")
+		b.WriteString("🇺🇦 <i>" + strings.Repeat("Дуже довгий текст про школи &amp; вчителів. ", 6) + "</i>\n\n")
+	}
+	text := b.String()
+	for _, limit := range []int{500, 120} {
+		checkSplit(t, text, limit)
+	}
+}
+
+func checkSplit(t *testing.T, text string, limit int) {
+	parts := SplitHTML(text, limit)
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	var joined strings.Builder
+	for i, p := range parts {
+		if n := textLength(p); n > limit {
+			t.Errorf("limit %d: part %d has %d characters", limit, i, n)
+		}
+		if open := openTags(nil, p); len(open) != 0 {
+			t.Errorf("part %d leaves tags open: %v\n%s", i, open, p)
+		}
+		if strings.Count(p, "<") != strings.Count(p, ">") {
+			t.Errorf("part %d has a cut tag:\n%s", i, p)
+		}
+		if strings.Contains(p, "&amp") && strings.Count(p, "&amp;") != strings.Count(p, "&amp") {
+			t.Errorf("part %d has a cut entity", i)
+		}
+		joined.WriteString(tagRe.ReplaceAllString(p, ""))
+	}
+	// no text is lost (only whitespace at the cuts)
+	want := strings.Join(strings.Fields(tagRe.ReplaceAllString(text, "")), "")
+	got := strings.Join(strings.Fields(joined.String()), "")
+	if got != want {
+		t.Errorf("text lost while splitting")
+	}
+}
@@ -2,162 +2,435 @@ package telegram
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 	"unicode/utf8"
 )
 
-// SendMessage sends text message to Telegram chat/channel with retry logic
-func SendMessage(token, chatID, text string) error {
+// Result is a sent message as reported by the Bot API
+type Result struct {
+	MessageID   int64     `json:"message_id"`
+	ChatID      int64     `json:"chat_id"`
+	Date        time.Time `json:"date"`
+	PhotoFileID string    `json:"photo_file_id,omitempty"` // largest size of a sent photo; pass it as photo to resend without upload
+}
+
+// Error is a failed Bot API call with the details Telegram returned
+type Error struct {
+	HTTPStatus      int
+	Code            int           // error_code, usually equal to the HTTP status
+	Description     string        // e.g. "Bad Request: can't parse entities: ..."
+	RetryAfter      time.Duration // flood control wait (429)
+	MigrateToChatID int64         // the group became a supergroup with this id
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("telegram API error %d", e.Code)
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(" (retry after %s)", e.RetryAfter)
+	}
+	if e.MigrateToChatID != 0 {
+		msg += fmt.Sprintf(" (migrated to chat %d)", e.MigrateToChatID)
+	}
+	return msg
+}
+
+// apiMessage is the part of a sent Message we keep
+type apiMessage struct {
+	MessageID int64 `json:"message_id"`
+	Date      int64 `json:"date"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	Photo []struct {
+		FileID string `json:"file_id"`
+	} `json:"photo"` // sizes, smallest first
+}
+
+func (m apiMessage) result() *Result {
+	r := &Result{MessageID: m.MessageID, ChatID: m.Chat.ID, Date: time.Unix(m.Date, 0)}
+	if len(m.Photo) > 0 {
+		r.PhotoFileID = m.Photo[len(m.Photo)-1].FileID
+	}
+	return r
+}
+
+// apiResponse is the envelope of every Bot API response
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"` // a Message, or an array of them for sendMediaGroup
+	Parameters  *struct {
+		RetryAfter      int   `json:"retry_after"`
+		MigrateToChatID int64 `json:"migrate_to_chat_id"`
+	} `json:"parameters"`
+}
+
+// DefaultBaseURL is the public Bot API endpoint
+const DefaultBaseURL = "https://api.telegram.org"
+
+// InlineButton is one button of an inline keyboard (URL or callback)
+type InlineButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+// InlineKeyboard is reply markup with rows of buttons under the message
+type InlineKeyboard struct {
+	InlineKeyboard [][]InlineButton `json:"inline_keyboard"`
+}
+
+// Options are optional parameters of a sent message; the zero value posts HTML without link preview
+type Options struct {
+	ParseMode      string // "HTML" when empty, "MarkdownV2", or "none" for plain text
+	LinkPreview    bool   // let Telegram show a link preview (text messages only)
+	Silent         bool   // disable_notification
+	ProtectContent bool   // forbid forwarding and saving
+	ReplyTo        int64  // message_id to reply to; still posted if the original was deleted
+	ReplyMarkup    *InlineKeyboard
+}
+
+// Sender posts messages to a chat; *Client implements it and tests can substitute a fake
+type Sender interface {
+	SendMessage(ctx context.Context, chatID, text string, opts Options) (*Result, error)
+	SendPhoto(ctx context.Context, chatID, photoURL, caption string, opts Options) (*Result, error)
+	SendPhotoFile(ctx context.Context, chatID string, file InputFile, caption string, opts Options) (*Result, error)
+	SendMediaGroup(ctx context.Context, chatID string, media []InputMedia, opts Options) ([]*Result, error)
+}
+
+// Client implements Sender
+var _ Sender = (*Client)(nil)
+
+// Client calls the Bot API with one bot token, a shared HTTP client and flood limiter
+type Client struct {
+	token   string
+	baseURL string
+	http    *http.Client
+	limiter *rateLimiter
+}
+
+// NewClient creates a client for the public Bot API with a 30s request timeout and the documented flood limits
+func NewClient(token string) *Client {
+	return &Client{
+		token:   token,
+		baseURL: DefaultBaseURL,
+		http:    &http.Client{Timeout: 30 * time.Second},
+		limiter: newRateLimiter(DefaultLimits),
+	}
+}
+
+// SetBaseURL points the client at another Bot API server (local server, httptest in tests)
+func (c *Client) SetBaseURL(url string) {
+	if url != "" {
+		c.baseURL = strings.TrimRight(url, "/")
+	}
+}
+
+// SetHTTPClient replaces the HTTP client used for every call
+func (c *Client) SetHTTPClient(h *http.Client) {
+	if h != nil {
+		c.http = h
+	}
+}
+
+// SetRequestTimeout changes the per-call timeout (d <= 0 keeps the current value)
+func (c *Client) SetRequestTimeout(d time.Duration) {
+	if d > 0 {
+		c.http.Timeout = d
+	}
+}
+
+// SetLimits changes the flood limits; zero fields keep the documented defaults
+func (c *Client) SetLimits(l Limits) {
+	c.limiter.setLimits(l)
+}
+
+// withRetry runs send up to 3 times, waiting for the flood limits of chatID before every try.
+// Permanent errors are not retried; a 429 waits retry_after, other failures back off exponentially.
+// Waiting is aborted when ctx is cancelled.
+// count is the number of messages the call posts (album items count separately against the limits).
+func (c *Client) withRetry(ctx context.Context, chatID, what string, count int, send func() ([]*Result, error)) ([]*Result, error) {
 	maxRetries := 3
 
+	var lastErr error
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err := sendMessageOnce(token, chatID, text)
+		if err := c.limiter.wait(ctx, chatID, count); err != nil {
+			return nil, fmt.Errorf("can't send %s: %v", what, err)
+		}
+		res, err := send()
 		if err == nil {
-			log.Printf("Message sent to Telegram (try %d)", attempt)
-			return nil
+			log.Printf("%s sent to Telegram (try %d, message_id %d)", what, attempt, res[0].MessageID)
+			return res, nil
 		}
+		lastErr = err
+
+		log.Printf("Error send %s to Telegram (try %d/%d): %v", what, attempt, maxRetries, err)
 
-		log.Printf("Error send to Telegram (try %d/%d): %v", attempt, maxRetries, err)
+		if IsPermanent(err) {
+			return nil, fmt.Errorf("can't send %s: %w", what, err)
+		}
+		if wait := retryAfter(err); wait > 0 {
+			if wait > maxRetryAfter {
+				return nil, fmt.Errorf("can't send %s, flood wait too long: %w", what, err)
+			}
+			// the limiter holds the next try (and other sends to this chat) back
+			log.Printf("⏳ Telegram flood control, waiting %v", wait)
+			c.limiter.block(chatID, wait)
+			continue
+		}
 
 		if attempt < maxRetries {
 			// Exponential backoff: 2^attempt seconds
 			waitTime := time.Duration(1<<attempt) * time.Second
 			log.Printf("Wait %v before next try...", waitTime)
-			time.Sleep(waitTime)
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("can't send %s: %v", what, ctx.Err())
+			case <-time.After(waitTime):
+			}
 		}
 	}
 
-	return fmt.Errorf("can't send message after %d tries", maxRetries)
+	return nil, fmt.Errorf("can't send %s after %d tries: %w", what, maxRetries, lastErr)
 }
 
-// sendMessageOnce does one try to send message
-func sendMessageOnce(token, chatID, text string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token)
+// applyOptions adds the parameters shared by sendMessage and sendPhoto
+func applyOptions(payload map[string]interface{}, opts Options) {
+	switch opts.ParseMode {
+	case "":
+		payload["parse_mode"] = "HTML"
+	case "none":
+	default:
+		payload["parse_mode"] = opts.ParseMode
+	}
+	if opts.Silent {
+		payload["disable_notification"] = true
+	}
+	if opts.ProtectContent {
+		payload["protect_content"] = true
+	}
+	if opts.ReplyTo != 0 {
+		payload["reply_to_message_id"] = opts.ReplyTo
+		payload["allow_sending_without_reply"] = true
+	}
+	if opts.ReplyMarkup != nil {
+		payload["reply_markup"] = opts.ReplyMarkup
+	}
+}
 
+// SendMessage sends text message to Telegram chat/channel with retry logic
+func (c *Client) SendMessage(ctx context.Context, chatID, text string, opts Options) (*Result, error) {
+	what := "message"
+	if opts.ReplyTo != 0 {
+		what = "reply"
+	}
 	payload := map[string]interface{}{
 		"chat_id":                  chatID,
 		"text":                     text,
-		"parse_mode":               "HTML",
-		"disable_web_page_preview": true, // No link preview for clean
+		"disable_web_page_preview": !opts.LinkPreview,
 	}
+	applyOptions(payload, opts)
+	return c.sendOne(ctx, chatID, what, "sendMessage", payload)
+}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("error make JSON: %v", err)
+// SendPhoto sends a photo with optional caption to Telegram chat/channel with retry logic.
+// photoURL is an http(s) URL Telegram downloads itself, or the file_id of a photo sent before.
+func (c *Client) SendPhoto(ctx context.Context, chatID, photoURL, caption string, opts Options) (*Result, error) {
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"photo":   photoURL,
+		"caption": limitCaption(caption),
 	}
+	applyOptions(payload, opts)
+	return c.sendOne(ctx, chatID, "photo", "sendPhoto", payload)
+}
+
+// InputFile is a file uploaded with the request (multipart/form-data)
+type InputFile struct {
+	Name string // file name shown to Telegram, e.g. "photo.jpg"
+	Data []byte
+}
 
-	// Add timeout for HTTP request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Post(url, "application/json", bytes.NewBuffer(body))
+// SendPhotoFile uploads a photo with optional caption; the result carries its file_id for reuse with SendPhoto
+func (c *Client) SendPhotoFile(ctx context.Context, chatID string, file InputFile, caption string, opts Options) (*Result, error) {
+	fields := map[string]interface{}{
+		"chat_id": chatID,
+		"caption": limitCaption(caption),
+	}
+	applyOptions(fields, opts)
+	res, err := c.withRetry(ctx, chatID, "photo upload", 1, func() ([]*Result, error) {
+		return c.upload(ctx, "sendPhoto", fields, map[string]InputFile{"photo": file})
+	})
 	if err != nil {
-		return fmt.Errorf("error HTTP request: %v", err)
+		return nil, err
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			log.Printf("Warning: failed to close response body: %v", err)
+	return res[0], nil
+}
+
+// limitCaption trims a caption to Telegram's ~1024 chars, rune-aware
+func limitCaption(caption string) string {
+	if utf8.RuneCountInString(caption) > 1024 {
+		r := []rune(caption)
+		if len(r) > 1024 {
+			caption = string(r[:1024])
 		}
-	}(resp.Body)
+	}
+	return caption
+}
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("telegram API error: status %d", resp.StatusCode)
+// sendOne posts a single message with retries
+func (c *Client) sendOne(ctx context.Context, chatID, what, method string, payload interface{}) (*Result, error) {
+	res, err := c.withRetry(ctx, chatID, what, 1, func() ([]*Result, error) {
+		return c.call(ctx, method, payload)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return res[0], nil
+}
 
-	return nil
+// InputMedia is one photo of an album
+type InputMedia struct {
+	Type      string     `json:"type"`  // "photo"
+	Media     string     `json:"media"` // URL or file_id; ignored when File is set
+	Caption   string     `json:"caption,omitempty"`
+	ParseMode string     `json:"parse_mode,omitempty"`
+	File      *InputFile `json:"-"` // uploaded with the request
 }
 
-// SendMessageAllowPreview sends text message and allows link previews (disable_web_page_preview=false)
-func SendMessageAllowPreview(token, chatID, text string) error {
-	maxRetries := 3
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", token)
-		payload := map[string]interface{}{
-			"chat_id":                  chatID,
-			"text":                     text,
-			"parse_mode":               "HTML",
-			"disable_web_page_preview": false,
-		}
-		body, err := json.Marshal(payload)
-		if err != nil {
-			return fmt.Errorf("error make JSON: %v", err)
+// MaxAlbumSize is the most items sendMediaGroup accepts
+const MaxAlbumSize = 10
+
+// SendMediaGroup posts 2-10 photos as an album and returns one result per item.
+// Captions are parsed as opts.ParseMode (HTML by default); link preview and reply markup do not apply.
+func (c *Client) SendMediaGroup(ctx context.Context, chatID string, media []InputMedia, opts Options) ([]*Result, error) {
+	if len(media) < 2 || len(media) > MaxAlbumSize {
+		return nil, fmt.Errorf("album must have 2-%d items, got %d", MaxAlbumSize, len(media))
+	}
+	items := make([]InputMedia, len(media))
+	files := make(map[string]InputFile)
+	for i, m := range media {
+		if m.File != nil {
+			name := fmt.Sprintf("photo%d", i)
+			files[name] = *m.File
+			m.Media = "attach://" + name
 		}
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Post(url, "application/json", bytes.NewBuffer(body))
-		if err != nil {
-			log.Printf("Error HTTP request (try %d/%d): %v", attempt, maxRetries, err)
-		} else {
-			func() {
-				defer func() {
-					if err := resp.Body.Close(); err != nil {
-						log.Printf("Warning: failed to close response body: %v", err)
-					}
-				}()
-				if resp.StatusCode == 200 {
-					log.Printf("Message with preview sent to Telegram (try %d)", attempt)
-					return
-				}
-				log.Printf("Telegram API error (try %d/%d): status %d", attempt, maxRetries, resp.StatusCode)
-			}()
+		if m.Type == "" {
+			m.Type = "photo"
 		}
-		if attempt < maxRetries {
-			waitTime := time.Duration(1<<attempt) * time.Second
-			log.Printf("Wait %v before next try...", waitTime)
-			time.Sleep(waitTime)
+		m.Caption = limitCaption(m.Caption)
+		if m.Caption != "" && m.ParseMode == "" {
+			switch opts.ParseMode {
+			case "":
+				m.ParseMode = "HTML"
+			case "none":
+			default:
+				m.ParseMode = opts.ParseMode
+			}
 		}
+		items[i] = m
 	}
-	return fmt.Errorf("can't send message with preview after %d tries", maxRetries)
-}
 
-// SendPhoto sends a photo with optional caption to Telegram chat/channel with retry logic
-func SendPhoto(token, chatID, photoURL, caption string) error {
-	maxRetries := 3
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err := sendPhotoOnce(token, chatID, photoURL, caption)
-		if err == nil {
-			log.Printf("Photo sent to Telegram (try %d)", attempt)
-			return nil
-		}
-		log.Printf("Error send photo to Telegram (try %d/%d): %v", attempt, maxRetries, err)
-		if attempt < maxRetries {
-			waitTime := time.Duration(1<<attempt) * time.Second
-			log.Printf("Wait %v before next try...", waitTime)
-			time.Sleep(waitTime)
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"media":   items,
+	}
+	opts.ParseMode = "none" // set per item
+	opts.ReplyMarkup = nil  // not supported for albums
+	applyOptions(payload, opts)
+
+	res, err := c.withRetry(ctx, chatID, fmt.Sprintf("album of %d", len(items)), len(items), func() ([]*Result, error) {
+		if len(files) > 0 {
+			return c.upload(ctx, "sendMediaGroup", payload, files)
 		}
+		return c.call(ctx, "sendMediaGroup", payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for len(res) < len(items) {
+		res = append(res, &Result{})
 	}
-	return fmt.Errorf("can't send photo after %d tries", maxRetries)
+	return res, nil
 }
 
-func sendPhotoOnce(token, chatID, photoURL, caption string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendPhoto", token)
-	// Telegram caption max ~1024 chars; trim rune-aware if longer
-	if utf8.RuneCountInString(caption) > 1024 {
-		r := []rune(caption)
-		if len(r) > 1024 {
-			caption = string(r[:1024])
-		}
+// call does one Bot API call; failures reported by Telegram are returned as *Error
+func (c *Client) call(ctx context.Context, method string, payload interface{}) ([]*Result, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error make JSON: %v", err)
 	}
 
-	payload := map[string]interface{}{
-		"chat_id":    chatID,
-		"photo":      photoURL,
-		"caption":    caption,
-		"parse_mode": "HTML",
+	return c.post(ctx, method, "application/json", body)
+}
+
+// upload does one Bot API call as multipart/form-data with files attached under their field names.
+// Non-string fields are sent as Telegram expects them in forms: numbers and bools as text, objects as JSON.
+func (c *Client) upload(ctx context.Context, method string, fields map[string]interface{}, files map[string]InputFile) ([]*Result, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, v := range fields {
+		var value string
+		switch v := v.(type) {
+		case string:
+			value = v
+		case bool:
+			value = strconv.FormatBool(v)
+		case int64:
+			value = strconv.FormatInt(v, 10)
+		case int:
+			value = strconv.Itoa(v)
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("error make JSON for %s: %v", name, err)
+			}
+			value = string(data)
+		}
+		if err := w.WriteField(name, value); err != nil {
+			return nil, fmt.Errorf("error make form: %v", err)
+		}
+	}
+	for name, f := range files {
+		part, err := w.CreateFormFile(name, f.Name)
+		if err != nil {
+			return nil, fmt.Errorf("error make form: %v", err)
+		}
+		if _, err := part.Write(f.Data); err != nil {
+			return nil, fmt.Errorf("error make form: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error make form: %v", err)
 	}
+	return c.post(ctx, method, w.FormDataContentType(), body.Bytes())
+}
 
-	body, err := json.Marshal(payload)
+// post sends a prepared request body to method
+func (c *Client) post(ctx context.Context, method, contentType string, body []byte) ([]*Result, error) {
+	url := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("error make JSON: %v", err)
+		return nil, fmt.Errorf("error make request: %v", err)
 	}
+	req.Header.Set("Content-Type", contentType)
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Post(url, "application/json", bytes.NewBuffer(body))
+	resp, err := c.http.Do(req)
 	if err != nil {
-		return fmt.Errorf("error HTTP request: %v", err)
+		return nil, fmt.Errorf("error HTTP request: %v", redactToken(err, c.token))
 	}
 	defer func(Body io.ReadCloser) {
 		if err := Body.Close(); err != nil {
@@ -165,8 +438,53 @@ func sendPhotoOnce(token, chatID, photoURL, caption string) error {
 		}
 	}(resp.Body)
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("telegram API error: status %d", resp.StatusCode)
+	return parseResponse(resp)
+}
+
+// redactToken keeps the bot token (part of the URL) out of logged errors
+func redactToken(err error, token string) string {
+	if token == "" {
+		return err.Error()
+	}
+	return strings.ReplaceAll(err.Error(), token, "<token>")
+}
+
+// parseResponse turns a Bot API response into results (one per sent message) or an *Error
+func parseResponse(resp *http.Response) ([]*Result, error) {
+	var r apiResponse
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&r)
+
+	if resp.StatusCode != http.StatusOK || (decodeErr == nil && !r.OK) {
+		apiErr := &Error{HTTPStatus: resp.StatusCode, Code: r.ErrorCode, Description: r.Description}
+		if apiErr.Code == 0 {
+			apiErr.Code = resp.StatusCode
+		}
+		if r.Parameters != nil {
+			apiErr.RetryAfter = time.Duration(r.Parameters.RetryAfter) * time.Second
+			apiErr.MigrateToChatID = r.Parameters.MigrateToChatID
+		}
+		return nil, apiErr
+	}
+
+	var messages []apiMessage
+	if decodeErr == nil {
+		if trimmed := bytes.TrimSpace(r.Result); len(trimmed) > 0 && trimmed[0] == '[' {
+			decodeErr = json.Unmarshal(trimmed, &messages)
+		} else {
+			var m apiMessage
+			if decodeErr = json.Unmarshal(trimmed, &m); decodeErr == nil {
+				messages = append(messages, m)
+			}
+		}
+	}
+	if decodeErr != nil || len(messages) == 0 {
+		// The message is posted; only its details are unknown, so this is not a failure
+		log.Printf("Warning: failed to decode Telegram response: %v", decodeErr)
+		return []*Result{{}}, nil
+	}
+	results := make([]*Result, len(messages))
+	for i, m := range messages {
+		results[i] = m.result()
 	}
-	return nil
+	return results, nil
 }
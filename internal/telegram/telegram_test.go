@@ -0,0 +1,168 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func response(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestParseResponse_Result(t *testing.T) {
+	results, err := parseResponse(response(200, `{"ok":true,"result":{"message_id":42,"date":1700000000,"chat":{"id":-100123}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res := results[0]
+	if res.MessageID != 42 || res.ChatID != -100123 || !res.Date.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestParseResponse_Error(t *testing.T) {
+	_, err := parseResponse(response(429, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 7","parameters":{"retry_after":7}}`))
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if apiErr.Code != 429 || apiErr.RetryAfter != 7*time.Second {
+		t.Errorf("unexpected error: %+v", apiErr)
+	}
+
+	_, err = parseResponse(response(400, `{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-100999}}`))
+	if !errors.As(err, &apiErr) || apiErr.MigrateToChatID != -100999 {
+		t.Errorf("expected migrate_to_chat_id, got %v", err)
+	}
+
+	_, err = parseResponse(response(502, `<html>Bad Gateway</html>`))
+	if !errors.As(err, &apiErr) || apiErr.Code != 502 {
+		t.Errorf("expected HTTP status as code, got %v", err)
+	}
+}
+
+func TestClient_SendMessage(t *testing.T) {
+	var got map[string]interface{}
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&got)
+		io.WriteString(w, `{"ok":true,"result":{"message_id":7,"date":1700000000,"chat":{"id":-100123}}}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient("TOKEN")
+	c.SetBaseURL(srv.URL)
+	res, err := c.SendMessage(context.Background(), "@news", "<b>hi</b>", Options{
+		Silent:      true,
+		ReplyTo:     5,
+		ReplyMarkup: &InlineKeyboard{InlineKeyboard: [][]InlineButton{{{Text: "Read", URL: "https://example.com"}}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.MessageID != 7 || res.ChatID != -100123 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if path != "/botTOKEN/sendMessage" {
+		t.Errorf("unexpected path %q", path)
+	}
+	if got["parse_mode"] != "HTML" || got["disable_notification"] != true || got["disable_web_page_preview"] != true ||
+		got["reply_to_message_id"] != float64(5) || got["reply_markup"] == nil {
+		t.Errorf("unexpected payload: %v", got)
+	}
+	if _, ok := got["protect_content"]; ok {
+		t.Errorf("protect_content should be omitted: %v", got)
+	}
+}
+
+func TestClient_PermanentErrorNotRetried(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities"}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient("TOKEN")
+	c.SetBaseURL(srv.URL)
+	_, err := c.SendPhoto(context.Background(), "@news", "https://example.com/a.jpg", "<b>broken", Options{})
+	if !IsPermanent(err) {
+		t.Fatalf("expected permanent error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("permanent error retried: %d calls", calls)
+	}
+}
+
+func TestClient_SendMediaGroup(t *testing.T) {
+	var got struct {
+		Media []InputMedia `json:"media"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		io.WriteString(w, `{"ok":true,"result":[{"message_id":10,"chat":{"id":-1}},{"message_id":11,"chat":{"id":-1}}]}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient("TOKEN")
+	c.SetBaseURL(srv.URL)
+	res, err := c.SendMediaGroup(context.Background(), "@news", []InputMedia{
+		{Media: "https://example.com/a.jpg", Caption: "<b>A</b>"},
+		{Media: "https://example.com/b.jpg"},
+	}, Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 2 || res[0].MessageID != 10 || res[1].MessageID != 11 {
+		t.Errorf("unexpected results: %+v", res)
+	}
+	if len(got.Media) != 2 || got.Media[0].Type != "photo" || got.Media[0].ParseMode != "HTML" || got.Media[1].ParseMode != "" {
+		t.Errorf("unexpected media: %+v", got.Media)
+	}
+
+	if _, err := c.SendMediaGroup(context.Background(), "@news", got.Media[:1], Options{}); err == nil {
+		t.Errorf("expected error for a single-item album")
+	}
+}
+
+func TestClient_SendPhotoFile(t *testing.T) {
+	var fields map[string][]string
+	var photo []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("not a multipart request: %v", err)
+		}
+		fields = r.MultipartForm.Value
+		if f, _, err := r.FormFile("photo"); err == nil {
+			photo, _ = io.ReadAll(f)
+		}
+		io.WriteString(w, `{"ok":true,"result":{"message_id":8,"chat":{"id":-1},"photo":[{"file_id":"small"},{"file_id":"large"}]}}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient("TOKEN")
+	c.SetBaseURL(srv.URL)
+	res, err := c.SendPhotoFile(context.Background(), "@news", InputFile{Name: "photo.jpeg", Data: []byte("JPEG")}, "<b>A</b>", Options{ReplyTo: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.MessageID != 8 || res.PhotoFileID != "large" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if string(photo) != "JPEG" {
+		t.Errorf("unexpected photo %q", photo)
+	}
+	if fields["chat_id"][0] != "@news" || fields["parse_mode"][0] != "HTML" || fields["reply_to_message_id"][0] != "3" ||
+		fields["allow_sending_without_reply"][0] != "true" {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+}
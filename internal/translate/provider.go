@@ -0,0 +1,453 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/deusflow/News/internal/storage"
+)
+
+// Capability is a bit set describing what an AI provider can do
+type Capability int
+
+const (
+	// CapTranslate - provider can translate plain text between languages
+	CapTranslate Capability = 1 << iota
+	// CapSummarize - provider can write a short summary in a given language
+	CapSummarize
+	// CapNews - provider implements NewsProvider (summary + Danish + Ukrainian in one request)
+	CapNews
+)
+
+// Has reports whether all bits of c2 are set in c
+func (c Capability) Has(c2 Capability) bool {
+	return c&c2 == c2
+}
+
+// Provider is a single AI/translation backend (Gemini, Groq, Cohere, Mistral, Google Translate...)
+type Provider interface {
+	Name() string
+	Capabilities() Capability
+	Translate(ctx context.Context, text, from, to string) (string, error)
+	Summarize(ctx context.Context, text, lang string) (string, error)
+}
+
+// NewsTranslation is the bilingual result of processing a single news article
+// UkrainianTitle, Category and Confidence are optional; providers that don't fill them leave zero values.
+type NewsTranslation struct {
+	Summary        string
+	Danish         string
+	Ukrainian      string
+	UkrainianTitle string
+	Category       string
+	Confidence     float64
+}
+
+// NewsProvider is implemented by providers that can summarize and translate a whole article in one request
+type NewsProvider interface {
+	Provider
+	TranslateAndSummarizeNews(ctx context.Context, title, content string) (*NewsTranslation, error)
+}
+
+// NewsArticle is one item of a batch request; ID is used to map results back
+type NewsArticle struct {
+	ID      string
+	Title   string
+	Content string
+}
+
+// BatchNewsProvider can process several articles in one request.
+// Results are keyed by NewsArticle.ID; articles missing from the map failed and should be retried one by one.
+// called reports whether an API request was made, so answers served from cache don't count against the budget.
+type BatchNewsProvider interface {
+	NewsProvider
+	TranslateAndSummarizeBatch(ctx context.Context, articles []NewsArticle) (results map[string]*NewsTranslation, called bool, err error)
+}
+
+// DefaultProviderOrder is used when no explicit order is configured
+var DefaultProviderOrder = []string{"gemini", "groq", "cohere", "mistral", "google"}
+
+// builtinProviders constructs the providers shipped with this package by name
+var builtinProviders = map[string]func() Provider{
+	"gemini":  newGeminiProvider,
+	"groq":    newGroqProvider,
+	"cohere":  newCohereProvider,
+	"mistral": newMistralProvider,
+	"google":  newGoogleProvider,
+}
+
+// Limiter reserves quota for a provider call (implemented by ratelimit.AIRateLimiter)
+type Limiter interface {
+	Use(provider string) error
+}
+
+// cacheHitRecorder is implemented by limiters that track tokens saved by caching
+type cacheHitRecorder interface {
+	RecordCacheHit(estimatedTokens int)
+}
+
+// Registry keeps an ordered list of providers and runs fallback chains over them
+type Registry struct {
+	mu        sync.RWMutex
+	providers []Provider
+	limiter   Limiter
+	store     storage.TranslationStore
+	timeout   time.Duration
+}
+
+// NewRegistry creates a registry with providers in the given order
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+// BuildRegistry creates a registry from provider names (e.g. from config).
+// Custom providers override built-in ones with the same name; unknown names are an error.
+func BuildRegistry(names []string, custom ...Provider) (*Registry, error) {
+	if len(names) == 0 {
+		names = DefaultProviderOrder
+	}
+	overrides := make(map[string]Provider, len(custom))
+	for _, p := range custom {
+		if p != nil {
+			overrides[strings.ToLower(p.Name())] = p
+		}
+	}
+
+	r := &Registry{}
+	for _, raw := range names {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		if p, ok := overrides[name]; ok {
+			r.Register(p)
+			continue
+		}
+		factory, ok := builtinProviders[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown AI provider %q", raw)
+		}
+		r.Register(factory())
+	}
+	return r, nil
+}
+
+// Register appends provider to the end of the chain, replacing a provider with the same name
+func (r *Registry) Register(p Provider) {
+	if p == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.providers {
+		if strings.EqualFold(existing.Name(), p.Name()) {
+			r.providers[i] = p
+			return
+		}
+	}
+	r.providers = append(r.providers, p)
+}
+
+// SetLimiter makes every Translate/Summarize call reserve quota before hitting a provider
+func (r *Registry) SetLimiter(l Limiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limiter = l
+}
+
+// SetRequestTimeout bounds every single provider call; 0 means only the caller's context applies
+func (r *Registry) SetRequestTimeout(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timeout = d
+}
+
+// callContext derives the context for one provider call
+func (r *Registry) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	r.mu.RLock()
+	d := r.timeout
+	r.mu.RUnlock()
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// SetStore makes Translate/Summarize consult a durable cache before calling any provider
+func (r *Registry) SetStore(store storage.TranslationStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+}
+
+// lookup returns a cached result for key; pick selects the field holding the result
+func (r *Registry) lookup(key string, pick func(storage.TranslationCacheItem) string) (string, bool) {
+	r.mu.RLock()
+	store, l := r.store, r.limiter
+	r.mu.RUnlock()
+	if store == nil {
+		return "", false
+	}
+
+	item, err := store.GetTranslationCache(key)
+	if err != nil {
+		log.Printf("⚠️ Translation cache lookup failed: %v", err)
+		return "", false
+	}
+	value := pick(item)
+	if item.ContentHash == "" || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	if rec, ok := l.(cacheHitRecorder); ok {
+		rec.RecordCacheHit(utf8.RuneCountInString(item.Content)/4 + utf8.RuneCountInString(value)/4)
+	}
+	return value, true
+}
+
+// remember stores a provider result in the durable cache
+func (r *Registry) remember(item storage.TranslationCacheItem) {
+	r.mu.RLock()
+	store := r.store
+	r.mu.RUnlock()
+	if store == nil {
+		return
+	}
+	if err := store.SetTranslationCache(item); err != nil {
+		log.Printf("⚠️ Failed to store translation in cache: %v", err)
+	}
+}
+
+// acquire reserves quota for provider p; nil limiter means unlimited
+func (r *Registry) acquire(p Provider) error {
+	r.mu.RLock()
+	l := r.limiter
+	r.mu.RUnlock()
+	if l == nil {
+		return nil
+	}
+	return l.Use(p.Name())
+}
+
+// Providers returns providers supporting capability c, in chain order
+func (r *Registry) Providers(c Capability) []Provider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Provider, 0, len(r.providers))
+	for _, p := range r.providers {
+		if p.Capabilities().Has(c) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Names returns provider names in chain order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.providers))
+	for _, p := range r.providers {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// NewsProvider returns the first provider able to process a whole article, or nil
+func (r *Registry) NewsProvider() NewsProvider {
+	for _, p := range r.Providers(CapNews) {
+		if np, ok := p.(NewsProvider); ok {
+			return np
+		}
+	}
+	return nil
+}
+
+// Without returns a copy of the registry without the named providers
+func (r *Registry) Without(names ...string) *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := &Registry{limiter: r.limiter, store: r.store, timeout: r.timeout}
+	for _, p := range r.providers {
+		skip := false
+		for _, n := range names {
+			if strings.EqualFold(p.Name(), n) {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			out.providers = append(out.providers, p)
+		}
+	}
+	return out
+}
+
+// Translate translates text with the first provider in the chain that succeeds.
+// If every provider fails the original text is returned; if ctx is cancelled its error is returned.
+func (r *Registry) Translate(ctx context.Context, text, from, to string) (string, error) {
+	// If text is empty, return as is
+	if text == "" {
+		return text, nil
+	}
+
+	// Normalize target language codes we support
+	target := strings.ToLower(strings.TrimSpace(to))
+	switch target {
+	case "uk", "ukrainian":
+		target = "uk"
+	case "da", "danish":
+		target = "da"
+	default:
+		// Unsupported target -> return original
+		return text, nil
+	}
+
+	// Clean text for translation
+	text = cleanTextForTranslation(text)
+
+	// Limit text length for API
+	originalText := text
+	if len(text) > 4000 {
+		text = text[:4000] + "..."
+	}
+
+	key := storage.ContentHash("translate", from, target, text)
+	pick := func(item storage.TranslationCacheItem) string {
+		if target == "da" {
+			return item.DanishTranslation
+		}
+		return item.UkrainianTranslation
+	}
+	if cached, ok := r.lookup(key, pick); ok {
+		log.Printf("💾 Cached translation %s->%s used", from, target)
+		return cached, nil
+	}
+
+	for _, p := range r.Providers(CapTranslate) {
+		if err := ctx.Err(); err != nil {
+			return originalText, err
+		}
+		if err := r.acquire(p); err != nil {
+			log.Printf("⚠️ %s skipped for %s->%s: %v", p.Name(), from, target, err)
+			continue
+		}
+		callCtx, cancel := r.callContext(ctx)
+		result, err := p.Translate(callCtx, text, from, target)
+		cancel()
+		if err == nil && result != "" && result != text {
+			log.Printf("✅ %s %s->%s ok", p.Name(), from, target)
+			result = SanitizeAIText(result)
+			item := storage.TranslationCacheItem{
+				ContentHash: key,
+				Title:       "translate:" + from + "->" + target,
+				Content:     text,
+				AIProvider:  p.Name(),
+			}
+			if target == "da" {
+				item.DanishTranslation = result
+			} else {
+				item.UkrainianTranslation = result
+			}
+			r.remember(item)
+			return result, nil
+		}
+		log.Printf("⚠️ %s not work for %s->%s: %v", p.Name(), from, target, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return originalText, err
+	}
+	log.Printf("⚠️ All translation services not work for %s->%s, use original", from, target)
+	return originalText, nil
+}
+
+// Summarize produces a short summary in lang with the first provider in the chain that succeeds
+func (r *Registry) Summarize(ctx context.Context, text, lang string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", nil
+	}
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		lang = "da"
+	}
+
+	// Clean and truncate
+	input := cleanTextForTranslation(text)
+	if len(input) > 4500 {
+		input = input[:4500] + "..."
+	}
+
+	key := storage.ContentHash("summarize", lang, input)
+	pick := func(item storage.TranslationCacheItem) string { return item.Summary }
+	if cached, ok := r.lookup(key, pick); ok {
+		log.Printf("💾 Cached %s summary used", lang)
+		return cached, nil
+	}
+
+	for _, p := range r.Providers(CapSummarize) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if err := r.acquire(p); err != nil {
+			log.Printf("⚠️ %s summarize skipped: %v", p.Name(), err)
+			continue
+		}
+		callCtx, cancel := r.callContext(ctx)
+		s, err := p.Summarize(callCtx, input, lang)
+		cancel()
+		if err == nil && strings.TrimSpace(s) != "" {
+			s = SanitizeAIText(s)
+			r.remember(storage.TranslationCacheItem{
+				ContentHash: key,
+				Title:       "summarize:" + lang,
+				Content:     input,
+				Summary:     s,
+				AIProvider:  p.Name(),
+			})
+			return s, nil
+		}
+		log.Printf("⚠️ %s summarize failed: %v", p.Name(), err)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("all summarizers failed")
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultRegistry *Registry
+)
+
+// Default returns the registry used by TranslateText and SummarizeText
+func Default() *Registry {
+	defaultMu.RLock()
+	r := defaultRegistry
+	defaultMu.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultRegistry == nil {
+		defaultRegistry, _ = BuildRegistry(DefaultProviderOrder)
+	}
+	return defaultRegistry
+}
+
+// SetDefault replaces the registry used by TranslateText and SummarizeText (nil restores built-ins)
+func SetDefault(r *Registry) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultRegistry = r
+}
@@ -0,0 +1,130 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/deusflow/News/internal/storage"
+)
+
+type fakeProvider struct {
+	name   string
+	caps   Capability
+	result string
+	err    error
+	calls  int
+}
+
+func (f *fakeProvider) Name() string             { return f.name }
+func (f *fakeProvider) Capabilities() Capability { return f.caps }
+
+func (f *fakeProvider) Translate(ctx context.Context, text, from, to string) (string, error) {
+	f.calls++
+	return f.result, f.err
+}
+
+func (f *fakeProvider) Summarize(ctx context.Context, text, lang string) (string, error) {
+	f.calls++
+	return f.result, f.err
+}
+
+func TestRegistry_TranslateFallsBackInOrder(t *testing.T) {
+	broken := &fakeProvider{name: "broken", caps: CapTranslate, err: errors.New("quota exceeded")}
+	good := &fakeProvider{name: "good", caps: CapTranslate, result: "Привіт, світ"}
+	unused := &fakeProvider{name: "unused", caps: CapTranslate, result: "never"}
+
+	r := NewRegistry(broken, good, unused)
+	out, err := r.Translate(context.Background(), "Hej verden, dette er en test", "da", "uk")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "Привіт, світ" {
+		t.Errorf("got %q, want translation from second provider", out)
+	}
+	if broken.calls != 1 || good.calls != 1 || unused.calls != 0 {
+		t.Errorf("unexpected call counts: broken=%d good=%d unused=%d", broken.calls, good.calls, unused.calls)
+	}
+}
+
+func TestRegistry_SummarizeSkipsProvidersWithoutCapability(t *testing.T) {
+	translator := &fakeProvider{name: "translator", caps: CapTranslate, result: "wrong"}
+	summarizer := &fakeProvider{name: "summarizer", caps: CapTranslate | CapSummarize, result: "Kort resumé."}
+
+	r := NewRegistry(translator, summarizer)
+	out, err := r.Summarize(context.Background(), "Lang tekst om noget vigtigt i Danmark.", "da")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "Kort resumé." {
+		t.Errorf("got %q", out)
+	}
+	if translator.calls != 0 {
+		t.Errorf("translate-only provider must not be asked to summarize")
+	}
+}
+
+func TestBuildRegistry_OrderOverridesAndUnknown(t *testing.T) {
+	custom := &fakeProvider{name: "gemini", caps: CapTranslate | CapSummarize}
+	r, err := BuildRegistry([]string{"google", " Gemini "}, custom)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := r.Names()
+	if len(names) != 2 || names[0] != "google" || names[1] != "gemini" {
+		t.Fatalf("unexpected order: %v", names)
+	}
+	if got := r.Providers(CapSummarize); len(got) != 1 || got[0] != Provider(custom) {
+		t.Errorf("custom provider should replace built-in gemini, got %v", got)
+	}
+	if without := r.Without("gemini").Names(); len(without) != 1 || without[0] != "google" {
+		t.Errorf("Without() = %v", without)
+	}
+
+	if _, err := BuildRegistry([]string{"gemini", "nope"}); err == nil {
+		t.Errorf("expected error for unknown provider")
+	}
+}
+
+func TestRegistry_TranslationStoreSkipsProviderOnRepeat(t *testing.T) {
+	provider := &fakeProvider{name: "groq", caps: CapTranslate | CapSummarize, result: "Коротко про головне."}
+	store := storage.NewFileTranslationCache(filepath.Join(t.TempDir(), "translation_cache.json"))
+
+	r := NewRegistry(provider)
+	r.SetStore(store)
+	for i := 0; i < 2; i++ {
+		out, err := r.Summarize(context.Background(), "Regeringen præsenterer en ny plan for ukrainske flygtninge.", "uk")
+		if err != nil || out != "Коротко про головне." {
+			t.Fatalf("run %d: got %q, %v", i, out, err)
+		}
+	}
+	if provider.calls != 1 {
+		t.Errorf("provider called %d times, want 1 (second call must come from cache)", provider.calls)
+	}
+
+	item, _ := store.GetTranslationCache(storage.ContentHash("summarize", "uk", "Regeringen præsenterer en ny plan for ukrainske flygtninge."))
+	if item.AIProvider != "groq" || item.UseCount < 2 {
+		t.Errorf("cache item not tracked: provider=%q uses=%d", item.AIProvider, item.UseCount)
+	}
+}
+
+func TestRegistry_CancelledContextStopsFallback(t *testing.T) {
+	broken := &fakeProvider{name: "broken", caps: CapTranslate | CapSummarize, err: errors.New("timeout")}
+	good := &fakeProvider{name: "good", caps: CapTranslate | CapSummarize, result: "never"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := NewRegistry(broken, good)
+	if _, err := r.Summarize(ctx, "Lang tekst om noget vigtigt i Danmark.", "da"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Summarize error = %v, want context.Canceled", err)
+	}
+	out, err := r.Translate(ctx, "Hej verden", "da", "uk")
+	if !errors.Is(err, context.Canceled) || out != "Hej verden" {
+		t.Errorf("Translate = %q, %v; want original text and context.Canceled", out, err)
+	}
+	if broken.calls != 0 || good.calls != 0 {
+		t.Errorf("providers must not be called after cancellation: broken=%d good=%d", broken.calls, good.calls)
+	}
+}
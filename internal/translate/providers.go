@@ -0,0 +1,342 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// postJSON sends payload to apiURL (optionally with bearer token) and returns the raw response body
+func postJSON(ctx context.Context, name, apiURL, apiKey string, payload interface{}) ([]byte, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	// Create HTTP client with timeout
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP error: %v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Warning: failed to close %s response body: %v", name, closeErr)
+		}
+	}()
+
+	if resp.StatusCode == 429 {
+		return nil, fmt.Errorf("quota exceeded (too many requests)")
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("%s API returned status %d: %s", name, resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// geminiProvider uses Gemini REST API for high-quality translation
+type geminiProvider struct {
+	apiKey string
+}
+
+func newGeminiProvider() Provider {
+	return &geminiProvider{apiKey: os.Getenv("GEMINI_API_KEY")}
+}
+
+func (p *geminiProvider) Name() string             { return "gemini" }
+func (p *geminiProvider) Capabilities() Capability { return CapTranslate }
+
+func (p *geminiProvider) Translate(ctx context.Context, text, from, to string) (string, error) {
+	if p.apiKey == "" {
+		return "", errors.New("GEMINI_API_KEY not set")
+	}
+
+	// Gemini API endpoint - используем самую новую стабильную версию Gemini 2.5 Flash
+	apiURL := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash:generateContent?key=%s", p.apiKey)
+	prompt := fmt.Sprintf(`Translate the following text from %s to %s. Return ONLY the translation, no explanations:\n\n%s`, from, LanguageName(to), text)
+
+	payload := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"parts": []map[string]interface{}{{"text": prompt}}},
+		},
+		"generationConfig": map[string]interface{}{
+			"temperature":     0.1,
+			"maxOutputTokens": 1000,
+		},
+	}
+
+	body, err := postJSON(ctx, "gemini", apiURL, "", payload)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("error parsing response: %v", err)
+	}
+	if len(response.Candidates) == 0 {
+		return "", errors.New("no candidates in response")
+	}
+	parts := response.Candidates[0].Content.Parts
+	if len(parts) == 0 {
+		return "", errors.New("no parts in content")
+	}
+	return strings.TrimSpace(parts[0].Text), nil
+}
+
+func (p *geminiProvider) Summarize(ctx context.Context, text, lang string) (string, error) {
+	return "", errors.New("gemini REST provider does not summarize")
+}
+
+// chatProvider talks to OpenAI-compatible chat completion APIs (Groq, Mistral)
+type chatProvider struct {
+	name            string
+	envKey          string
+	apiURL          string
+	model           string
+	apiKey          string
+	summarizePrompt string
+}
+
+func newGroqProvider() Provider {
+	return &chatProvider{
+		name:            "groq",
+		envKey:          "GROQ_API_KEY",
+		apiURL:          "https://api.groq.com/openai/v1/chat/completions",
+		model:           "llama-3.1-8b-instant",
+		apiKey:          os.Getenv("GROQ_API_KEY"),
+		summarizePrompt: "Summarize the text in %s in 3-4 concise sentences. No preface, no lists, plain text.\n\nTEXT:\n%s",
+	}
+}
+
+func newMistralProvider() Provider {
+	return &chatProvider{
+		name:            "mistral",
+		envKey:          "MISTRALAI_API_KEY",
+		apiURL:          "https://api.mistral.ai/v1/chat/completions",
+		model:           "mistral-tiny", // Free tier model
+		apiKey:          os.Getenv("MISTRALAI_API_KEY"),
+		summarizePrompt: "Summarize the text in %s in 3-4 concise sentences. No bullet points.\n\nTEXT:\n%s",
+	}
+}
+
+func (p *chatProvider) Name() string             { return p.name }
+func (p *chatProvider) Capabilities() Capability { return CapTranslate | CapSummarize }
+
+func (p *chatProvider) Translate(ctx context.Context, text, from, to string) (string, error) {
+	prompt := fmt.Sprintf(`Translate the following text from %s to %s. Return ONLY the translation, no explanations or additional text:\n\n%s`, from, LanguageName(to), text)
+	return p.complete(ctx, prompt, 0.1, 1000)
+}
+
+func (p *chatProvider) Summarize(ctx context.Context, text, lang string) (string, error) {
+	return p.complete(ctx, fmt.Sprintf(p.summarizePrompt, LanguageName(lang), text), 0.2, 600)
+}
+
+func (p *chatProvider) complete(ctx context.Context, prompt string, temperature float64, maxTokens int) (string, error) {
+	if p.apiKey == "" {
+		return "", fmt.Errorf("%s not set", p.envKey)
+	}
+
+	payload := map[string]interface{}{
+		"model": p.model,
+		"messages": []map[string]interface{}{
+			{"role": "user", "content": prompt},
+		},
+		"temperature": temperature,
+		"max_tokens":  maxTokens,
+	}
+
+	body, err := postJSON(ctx, p.name, p.apiURL, p.apiKey, payload)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("error parsing response: %v", err)
+	}
+	if len(response.Choices) == 0 {
+		return "", errors.New("no choices in response")
+	}
+	return strings.TrimSpace(response.Choices[0].Message.Content), nil
+}
+
+// cohereProvider uses Cohere generate API (FREE 100 requests/month)
+type cohereProvider struct {
+	apiKey string
+}
+
+func newCohereProvider() Provider {
+	return &cohereProvider{apiKey: os.Getenv("COHERE_API_KEY")}
+}
+
+func (p *cohereProvider) Name() string             { return "cohere" }
+func (p *cohereProvider) Capabilities() Capability { return CapTranslate | CapSummarize }
+
+func (p *cohereProvider) Translate(ctx context.Context, text, from, to string) (string, error) {
+	targetName := LanguageName(to)
+	prompt := fmt.Sprintf(`Translate from %s to %s. Return only the translation:\n\n%s\n\n%s translation:`, from, targetName, text, targetName)
+	return p.generate(ctx, prompt, 0.1)
+}
+
+func (p *cohereProvider) Summarize(ctx context.Context, text, lang string) (string, error) {
+	prompt := fmt.Sprintf("Summarize the following text in %s in 3-4 concise sentences. No lists, no meta text.\n\nTEXT:\n%s\n\nSummary:", LanguageName(lang), text)
+	return p.generate(ctx, prompt, 0.2)
+}
+
+func (p *cohereProvider) generate(ctx context.Context, prompt string, temperature float64) (string, error) {
+	if p.apiKey == "" {
+		return "", errors.New("COHERE_API_KEY not set")
+	}
+
+	payload := map[string]interface{}{
+		"model":       "command-light", // Free tier model
+		"prompt":      prompt,
+		"max_tokens":  500,
+		"temperature": temperature,
+	}
+
+	body, err := postJSON(ctx, "cohere", "https://api.cohere.ai/v1/generate", p.apiKey, payload)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Generations []struct {
+			Text string `json:"text"`
+		} `json:"generations"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("error parsing response: %v", err)
+	}
+	if len(response.Generations) == 0 {
+		return "", errors.New("no generations in response")
+	}
+	return strings.TrimSpace(response.Generations[0].Text), nil
+}
+
+// googleProvider uses FREE public Google Translate endpoint (ultimate fallback)
+type googleProvider struct{}
+
+func newGoogleProvider() Provider {
+	return googleProvider{}
+}
+
+func (googleProvider) Name() string             { return "google" }
+func (googleProvider) Capabilities() Capability { return CapTranslate }
+
+func (googleProvider) Translate(ctx context.Context, text, from, to string) (string, error) {
+	baseURL := "https://translate.googleapis.com/translate_a/single"
+
+	// Build query params
+	params := url.Values{}
+	params.Set("client", "gtx")
+	params.Set("sl", from) // source language: use parameter
+	params.Set("tl", to)   // target language: use parameter
+	params.Set("dt", "t")  // return translations
+	params.Set("q", text)
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("HTTP error: %v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("google Translate API returned status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response: %v", err)
+	}
+
+	translation, err := parseGoogleTranslateResponse(body)
+	if err != nil {
+		return "", fmt.Errorf("error parsing response: %v", err)
+	}
+	return translation, nil
+}
+
+func (googleProvider) Summarize(ctx context.Context, text, lang string) (string, error) {
+	return "", errors.New("google translate does not summarize")
+}
+
+// parseGoogleTranslateResponse parses Google Translate API response
+func parseGoogleTranslateResponse(body []byte) (string, error) {
+	// Google Translate returns array of arrays
+	var response []interface{}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+
+	if len(response) == 0 {
+		return "", errors.New("empty response from Google Translate")
+	}
+
+	// First element contains translations
+	translations, ok := response[0].([]interface{})
+	if !ok {
+		return "", errors.New("unexpected response format")
+	}
+
+	var result strings.Builder
+
+	// Collect all translation parts
+	for _, translation := range translations {
+		if translationArray, ok := translation.([]interface{}); ok && len(translationArray) > 0 {
+			if translatedText, ok := translationArray[0].(string); ok {
+				result.WriteString(translatedText)
+			}
+		}
+	}
+
+	return result.String(), nil
+}